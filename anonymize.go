@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/keskad/loco/uptime"
+)
+
+// anonymizeOffset picks how far --anonymize moves the history back: a whole
+// number of weeks, so weekdays and times of day keep their meaning
+func anonymizeOffset() time.Duration {
+	weeks := 52 + rand.IntN(520)
+	return -time.Duration(weeks) * 7 * 24 * time.Hour
+}
+
+// anonymizer shifts events and sessions by offset, drops their boot IDs and
+// the users who shut the machine down, and replaces host names with
+// "host-1", "host-2", ... Durations and the spacing between sessions are
+// kept.
+type anonymizer struct {
+	offset time.Duration
+	hosts  map[string]string
+}
+
+// newAnonymizer numbers the hosts of the events and sessions, so every part
+// of the report gets the same names
+func newAnonymizer(offset time.Duration, events []uptime.Event, sessions []uptime.Session) anonymizer {
+	hosts := map[string]string{}
+	for _, event := range events {
+		hosts[event.Host] = ""
+	}
+	for _, session := range sessions {
+		hosts[session.Host] = ""
+	}
+	names := []string{}
+	for host := range hosts {
+		if host != "" {
+			names = append(names, host)
+		}
+	}
+	sort.Strings(names)
+	for i, host := range names {
+		hosts[host] = fmt.Sprintf("host-%d", i+1)
+	}
+
+	return anonymizer{offset: offset, hosts: hosts}
+}
+
+func (a anonymizer) events(events []uptime.Event) []uptime.Event {
+	result := make([]uptime.Event, len(events))
+	for i, event := range events {
+		event.Timestamp = event.Timestamp.Add(a.offset)
+		event.BootID = ""
+		event.Host = a.hosts[event.Host]
+		event.Reason = anonymizeReason(event.Reason)
+		result[i] = event
+	}
+	return result
+}
+
+func (a anonymizer) sessions(sessions []uptime.Session) []uptime.Session {
+	result := make([]uptime.Session, len(sessions))
+	for i, session := range sessions {
+		session.Start = session.Start.Add(a.offset)
+		session.End = session.End.Add(a.offset)
+		session.BootID = ""
+		session.Host = a.hosts[session.Host]
+		session.Type = anonymizeReason(session.Type)
+		result[i] = session
+	}
+	return result
+}
+
+// window moves the --since/--until window along with the sessions
+func (a anonymizer) window(window uptime.TimeWindow) uptime.TimeWindow {
+	if !window.Since.IsZero() {
+		window.Since = window.Since.Add(a.offset)
+	}
+	if !window.Until.IsZero() {
+		window.Until = window.Until.Add(a.offset)
+	}
+	return window
+}
+
+// initiatorRegex matches the user --reason names, e.g. "user:alice"
+var initiatorRegex = regexp.MustCompile(`\buser:[^,)\s]+`)
+
+// anonymizeReason keeps that a user shut the machine down, but not who
+func anonymizeReason(text string) string {
+	return initiatorRegex.ReplaceAllString(text, "user")
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/keskad/loco/uptime"
+	"golang.org/x/term"
+)
+
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+)
+
+// An active session running longer than this is highlighted as suspicious
+const longActiveSession = 7 * 24 * time.Hour
+
+// colorEnabled reports whether ANSI colors should be used. Colors are off when
+// requested by flag or NO_COLOR (https://no-color.org) and when stdout is not
+// a terminal.
+func colorEnabled(noColor bool) bool {
+	if noColor {
+		return false
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+func sessionColor(session uptime.Session) string {
+	switch {
+	case uptime.IsCrash(session):
+		return colorRed
+	case strings.HasSuffix(session.Type, "(still active)"):
+		if session.Duration > longActiveSession {
+			return colorRed
+		}
+		return ""
+	case uptime.SessionEnd(session) == "suspend", uptime.SessionEnd(session) == "hibernate":
+		return colorYellow
+	case uptime.SessionEnd(session) == "shutdown":
+		return colorGreen
+	default:
+		return ""
+	}
+}
+
+// colorize wraps an already padded line, so escapes do not affect alignment
+func colorize(line, color string) string {
+	if color == "" {
+		return line
+	}
+	return color + line + colorReset
+}
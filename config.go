@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configPath returns ~/.config/uptime-history/config.yaml, honoring
+// XDG_CONFIG_HOME.
+func configPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "uptime-history", "config.yaml"), nil
+}
+
+// cachePath returns ~/.cache/uptime-history/journal.json, honoring
+// XDG_CACHE_HOME.
+func cachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "uptime-history", "journal.json"), nil
+}
+
+// applyConfigFile sets flag defaults from the config file. Keys are flag
+// names, e.g. "tz: UTC" or "json: true". It must be called before the
+// command line is parsed, so that flags given there take precedence. A
+// missing config file is not an error.
+func applyConfigFile(flags *flag.FlagSet, path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot read config file: %v", err)
+	}
+
+	values := map[string]string{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("cannot parse config file %s: %v", path, err)
+	}
+
+	// Apply in a stable order so errors are reproducible
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if flags.Lookup(key) == nil {
+			return fmt.Errorf("unknown option %q in config file %s", key, path)
+		}
+		if err := flags.Set(key, values[key]); err != nil {
+			return fmt.Errorf("invalid value for %q in config file %s: %v", key, path, err)
+		}
+	}
+
+	return nil
+}
+
+// formatFlags are the flags selecting the output format
+var formatFlags = []string{"format", "json", "json-pretty", "ndjson", "csv", "prometheus", "markdown", "html"}
+
+// applyEnvironment sets flag defaults from UPTIME_HISTORY_FORMAT (table,
+// json, csv, markdown, ...), UPTIME_HISTORY_TZ and JOURNALCTL. It is called
+// after applyConfigFile, so the environment takes precedence over the file.
+func applyEnvironment(flags *flag.FlagSet) error {
+	if journalctl := os.Getenv("JOURNALCTL"); journalctl != "" {
+		if err := flags.Set("journalctl", journalctl); err != nil {
+			return fmt.Errorf("invalid JOURNALCTL: %v", err)
+		}
+	}
+
+	if tz := os.Getenv("UPTIME_HISTORY_TZ"); tz != "" {
+		if err := flags.Set("tz", tz); err != nil {
+			return fmt.Errorf("invalid UPTIME_HISTORY_TZ: %v", err)
+		}
+	}
+
+	format := os.Getenv("UPTIME_HISTORY_FORMAT")
+	if format == "" {
+		return nil
+	}
+	if format != "table" && (format == "format" || !slices.Contains(formatFlags, format)) {
+		return fmt.Errorf("invalid UPTIME_HISTORY_FORMAT %q, expected table, json, json-pretty, ndjson, csv, prometheus, markdown or html", format)
+	}
+
+	clearFormats(flags, "")
+	if format != "table" {
+		return flags.Set(format, "true")
+	}
+	return nil
+}
+
+// setFlags returns the names of the flags set so far
+func setFlags(flags *flag.FlagSet) map[string]bool {
+	set := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	return set
+}
+
+// preferCommandLineFormat drops the output format chosen by the config file
+// or the environment when the command line chooses another one. before are
+// the flags set before the command line was parsed.
+func preferCommandLineFormat(flags *flag.FlagSet, before map[string]bool) {
+	for name := range setFlags(flags) {
+		if !before[name] && slices.Contains(formatFlags, name) {
+			clearFormats(flags, name)
+			return
+		}
+	}
+}
+
+// clearFormats resets all format flags but keep to their defaults
+func clearFormats(flags *flag.FlagSet, keep string) {
+	for _, name := range formatFlags {
+		if name != keep {
+			f := flags.Lookup(name)
+			f.Value.Set(f.DefValue)
+		}
+	}
+}
@@ -0,0 +1,626 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/keskad/loco/uptime"
+	"golang.org/x/term"
+)
+
+// renderSettings are the choices of how the report is printed, shared by
+// the table and the other formats
+type renderSettings struct {
+	// compactDurations prints "30d18h" instead of "30d 18h 0m", set by
+	// --compact
+	compactDurations bool
+	// timeLayout and shortTimeLayout are the layouts of the timestamps in
+	// sessions and in summaries, both replaced by --time-format
+	timeLayout      string
+	shortTimeLayout string
+	// relativeTimes prints session timestamps in the table like
+	// "3 days ago", set by --relative
+	relativeTimes bool
+	// prettyJSON indents JSON output by two spaces, set by --json-pretty
+	prettyJSON bool
+	// roundDurations rounds every printed duration to this unit, e.g. a
+	// minute prints "9h 4m" instead of "9h 3m 47s". Set by --round, totals
+	// are still calculated from the exact durations.
+	roundDurations time.Duration
+	// showBootID adds the short boot ID of each session to the table, set
+	// by --show-boot-id
+	showBootID bool
+	// groupByType adds the sessions per type to the summary, set by
+	// --group-by-type
+	groupByType bool
+}
+
+func defaultRenderSettings() renderSettings {
+	return renderSettings{
+		timeLayout:      "2006-01-02 15:04:05",
+		shortTimeLayout: "2006-01-02 15:04",
+	}
+}
+
+// formatTimestamp prints a session timestamp in the table
+func (settings renderSettings) formatTimestamp(t time.Time) string {
+	if settings.relativeTimes {
+		return formatAgo(now().Sub(t))
+	}
+	return t.Format(settings.timeLayout)
+}
+
+// formatAgo turns the time elapsed since a moment into "5 minutes ago",
+// "3 days ago" and the like
+func formatAgo(d time.Duration) string {
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s ago", unit)
+		}
+		return fmt.Sprintf("%d %ss ago", n, unit)
+	}
+
+	day := 24 * time.Hour
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return plural(int(d/time.Minute), "minute")
+	case d < day:
+		return plural(int(d/time.Hour), "hour")
+	case d < 60*day:
+		return plural(int(d/day), "day")
+	case d < 730*day:
+		return plural(int(d/(30*day)), "month")
+	default:
+		return plural(int(d/(365*day)), "year")
+	}
+}
+
+// validateTimeLayout rejects a layout without any reference time element,
+// which would print the same text for every timestamp
+func validateTimeLayout(layout string) error {
+	sample := time.Date(2025, 10, 28, 16, 28, 42, 0, time.UTC)
+	if layout == "" || sample.Format(layout) == layout {
+		return fmt.Errorf("%q contains no date or time, use Go's reference time, e.g. \"Jan _2 3:04PM\"", layout)
+	}
+	return nil
+}
+
+// Width of the table when stdout is not a terminal
+const defaultTableWidth = 110
+
+type tableLayout struct {
+	width  int
+	start  int
+	end    int
+	uptime int
+	// kind is the width of the Type column, zero means unlimited
+	kind int
+}
+
+// newTableLayout fits the columns into the terminal width. A zero width
+// means stdout is not a terminal and the classic layout is used.
+func newTableLayout(width int) tableLayout {
+	layout := tableLayout{width: width, start: 25, end: 25, uptime: 20}
+	if width <= 0 {
+		layout.width = defaultTableWidth
+		return layout
+	}
+
+	fixed := func() int {
+		return layout.start + layout.end + layout.uptime + len(" | ")*3
+	}
+
+	// Drop the padding on narrow terminals
+	if width-fixed() < 24 {
+		layout.start, layout.end, layout.uptime = 19, 19, 14
+	}
+
+	layout.kind = width - fixed()
+	if layout.kind < 8 {
+		layout.kind = 8
+	}
+	return layout
+}
+
+func (l tableLayout) row(start, end, uptime, kind string) string {
+	if l.kind > 0 {
+		kind = ellipsize(kind, l.kind)
+	}
+	return fmt.Sprintf("%-*s | %-*s | %-*s | %s", l.start, start, l.end, end, l.uptime, uptime, kind)
+}
+
+// ellipsize shortens s to at most n characters
+func ellipsize(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+func terminalWidth() int {
+	fd := int(os.Stdout.Fd())
+	if !term.IsTerminal(fd) {
+		return 0
+	}
+
+	width, _, err := term.GetSize(fd)
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+func displaySessions(w io.Writer, sessions []uptime.Session, maxRows int, width int, useColor bool, total time.Duration, settings renderSettings) {
+	// Determine how many rows to display
+	displayCount := len(sessions)
+	if maxRows > 0 && maxRows < displayCount {
+		displayCount = maxRows
+	}
+
+	// Display the last N sessions in reverse order (newest first)
+	rows := uptime.ReverseSessions(sessions[len(sessions)-displayCount:])
+	displayTable(w, "Computer work sessions:", rows, width, useColor, total, settings)
+
+	if displayCount < len(sessions) {
+		fmt.Fprintf(w, "\n(Showing last %d of %d sessions. Use -rows flag to show more)\n", displayCount, len(sessions))
+	}
+	fmt.Fprintln(w)
+}
+
+// displayTop lists the n longest sessions, longest first
+func displayTop(w io.Writer, sessions []uptime.Session, n int, width int, useColor bool, total time.Duration, settings renderSettings) {
+	displayTable(w, fmt.Sprintf("Longest %d sessions:", n), uptime.LongestSessions(sessions, n), width, useColor, total, settings)
+	fmt.Fprintln(w)
+}
+
+// shortBootIDLength is how much of a boot ID the table shows, enough for
+// journalctl -b
+const shortBootIDLength = 8
+
+func shortBootID(bootID string) string {
+	if bootID == "" {
+		return "-"
+	}
+	return bootID[:min(len(bootID), shortBootIDLength)]
+}
+
+// displayTable prints the sessions as a table in the given order. A non-zero
+// total adds a column with each session's share of it.
+func displayTable(w io.Writer, title string, sessions []uptime.Session, width int, useColor bool, total time.Duration, settings renderSettings) {
+	layout := newTableLayout(width)
+
+	// Sessions of several machines get a Host column in front of the type,
+	// --show-boot-id a Boot column and a total the share of it
+	hostWidth := 0
+	for _, host := range uptime.Hosts(sessions) {
+		hostWidth = max(hostWidth, len(host))
+	}
+	kind := func(share, host, bootID, kind string) string {
+		if settings.showBootID {
+			kind = fmt.Sprintf("%-*s | %s", shortBootIDLength, shortBootID(bootID), kind)
+		}
+		if hostWidth > 0 {
+			kind = fmt.Sprintf("%-*s | %s", hostWidth, host, kind)
+		}
+		if total > 0 {
+			kind = fmt.Sprintf("%10s | %s", share, kind)
+		}
+		return kind
+	}
+
+	fmt.Fprintln(w, title)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, layout.row("Start", "End", "Uptime", kind("% of total", "Host", "Boot", "Type")))
+	fmt.Fprintln(w, strings.Repeat("-", layout.width))
+
+	for _, session := range sessions {
+		sessionType := session.Type
+		if session.ClockAdjusted {
+			sessionType += " (clock adjusted)"
+		}
+
+		line := layout.row(
+			settings.formatTimestamp(session.Start),
+			settings.formatTimestamp(session.End),
+			settings.formatDuration(session.Duration),
+			kind(shareOfTotal(session, total), session.Host, session.BootID, sessionType),
+		)
+
+		if useColor {
+			line = colorize(line, sessionColor(session))
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// displayEvents lists the events sessions are calculated from, oldest first
+func displayEvents(w io.Writer, events []uptime.Event, settings renderSettings) {
+	fmt.Fprintln(w, "System events:")
+	fmt.Fprintln(w)
+	for _, event := range events {
+		line := fmt.Sprintf("%-25s %-10s %-32s", settings.formatTimestamp(event.Timestamp), event.Type, event.BootID)
+		if event.Host != "" {
+			line += " " + event.Host
+		}
+		if event.Reason != "" {
+			line += " (" + event.Reason + ")"
+		}
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+}
+
+// shareOfTotal prints the session's percentage of the total uptime, downtime
+// rows are not part of it
+func shareOfTotal(session uptime.Session, total time.Duration) string {
+	if total <= 0 || session.Type == uptime.DowntimeType {
+		return ""
+	}
+	return fmt.Sprintf("%.1f%%", 100*session.Duration.Seconds()/total.Seconds())
+}
+
+// annotateSuspends adds the sleep cycles of merged sessions to their type,
+// e.g. "boot → shutdown (3 suspends, 2h 0m 0s asleep)"
+func annotateSuspends(sessions []uptime.Session, settings renderSettings) []uptime.Session {
+	result := make([]uptime.Session, len(sessions))
+	for i, session := range sessions {
+		if session.Suspends > 0 {
+			session.Type += fmt.Sprintf(" (%s, %s asleep)", plural(session.Suspends, "suspend"), settings.formatDuration(session.AsleepWithin))
+		}
+		result[i] = session
+	}
+	return result
+}
+
+func displayCurrent(w io.Writer, sessions []uptime.Session, settings renderSettings) {
+	current, asleep, ok := uptime.CurrentSession(sessions)
+	switch {
+	case !ok:
+		fmt.Fprintln(w, "Current uptime: unknown")
+	case asleep:
+		fmt.Fprintf(w, "Currently suspended since %s\n", current.End.Format(settings.shortTimeLayout))
+	default:
+		fmt.Fprintf(w, "Current uptime: %s (since %s %s)\n",
+			settings.formatDuration(current.Duration),
+			uptime.SessionStart(current),
+			current.Start.Format(settings.shortTimeLayout),
+		)
+	}
+}
+
+func displaySummary(w io.Writer, summary uptime.Summary, settings renderSettings) {
+	if summary.Count == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "\n=== Summary ===")
+	fmt.Fprintf(w, "Number of sessions: %d\n", summary.Count)
+	fmt.Fprintf(w, "Total uptime: %s\n", settings.formatDuration(summary.Total))
+	fmt.Fprintf(w, "Suspended time: %s (%s)\n", settings.formatDuration(summary.Suspended), plural(summary.Suspends, "suspend"))
+	fmt.Fprintf(w, "Hibernated time: %s (%s)\n", settings.formatDuration(summary.Hibernated), plural(summary.Hibernations, "hibernation"))
+	fmt.Fprintf(w, "Average session time: %s\n", settings.formatDuration(summary.Average))
+	fmt.Fprintf(w, "Median session time: %s\n", settings.formatDuration(summary.Median))
+	fmt.Fprintf(w, "Standard deviation: %s\n", settings.formatDuration(summary.StdDev))
+	fmt.Fprintf(w, "Crashes: %d\n", summary.Crashes)
+	if summary.FailedSuspends > 0 {
+		fmt.Fprintf(w, "Failed suspends: %d%s\n", summary.FailedSuspends, failedHibernations(summary))
+	}
+
+	fmt.Fprintf(w, "\nLongest session: %s (%s)\n",
+		settings.formatDuration(summary.Longest.Duration),
+		summary.Longest.Start.Format(settings.shortTimeLayout),
+	)
+	fmt.Fprintf(w, "Shortest session: %s (%s)\n",
+		settings.formatDuration(summary.Shortest.Duration),
+		summary.Shortest.Start.Format(settings.shortTimeLayout),
+	)
+
+	if summary.BusiestDay.Uptime > 0 {
+		fmt.Fprintf(w, "Busiest day: %s with %s\n",
+			summary.BusiestDay.Day.Format("Mon 2006-01-02"),
+			settings.formatDuration(summary.BusiestDay.Uptime),
+		)
+		fmt.Fprintf(w, "Quietest day: %s with %s\n",
+			summary.QuietestDay.Day.Format("Mon 2006-01-02"),
+			settings.formatDuration(summary.QuietestDay.Uptime),
+		)
+		fmt.Fprintf(w, "Current streak: %s, longest: %s\n", plural(summary.CurrentStreak, "day"), plural(summary.LongestStreak, "day"))
+	}
+}
+
+// displayComparison prints how the summary changed since a previous report
+func displayComparison(w io.Writer, summary, previous uptime.Summary, path string, settings renderSettings) {
+	fmt.Fprintf(w, "\nCompared with %s:\n", path)
+	fmt.Fprintf(w, "Total uptime: %s %s\n", settings.signedDuration(summary.Total-previous.Total), trendArrow(int64(summary.Total-previous.Total)))
+	fmt.Fprintf(w, "Sessions: %+d %s\n", summary.Count-previous.Count, trendArrow(int64(summary.Count-previous.Count)))
+	fmt.Fprintf(w, "Crashes: %+d %s\n", summary.Crashes-previous.Crashes, trendArrow(int64(summary.Crashes-previous.Crashes)))
+}
+
+// plural prints a count with its noun, like "1 day" or "12 days"
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// failedHibernations tells how many failed suspends were hibernations,
+// e.g. " (1 hibernation)"
+func failedHibernations(summary uptime.Summary) string {
+	if summary.FailedHibernations == 0 {
+		return ""
+	}
+	return " (" + plural(summary.FailedHibernations, "hibernation") + ")"
+}
+
+// signedDuration is formatDuration with a plus sign in front of positive
+// durations
+func (settings renderSettings) signedDuration(d time.Duration) string {
+	if d > 0 {
+		return "+" + settings.formatDuration(d)
+	}
+	return settings.formatDuration(d)
+}
+
+func trendArrow(delta int64) string {
+	switch {
+	case delta > 0:
+		return "↑"
+	case delta < 0:
+		return "↓"
+	default:
+		return "="
+	}
+}
+
+// displayHosts breaks the summary down per machine, when there are several
+func displayHosts(w io.Writer, sessions []uptime.Session, settings renderSettings) {
+	hosts := uptime.Hosts(sessions)
+	if len(hosts) < 2 {
+		return
+	}
+
+	fmt.Fprintln(w, "\nUptime per host:")
+	for _, host := range hosts {
+		summary := uptime.Summarize(uptime.FilterSessionsByHost(sessions, host))
+		fmt.Fprintf(w, "%s: %s in %s, %s\n", host, settings.formatDuration(summary.Total), plural(summary.Count, "session"), plural(summary.Crashes, "crash"))
+	}
+}
+
+// displayByType prints the number and uptime of the sessions per type
+func displayByType(w io.Writer, summary uptime.Summary, settings renderSettings) {
+	if len(summary.ByType) == 0 {
+		return
+	}
+
+	width := 0
+	for _, total := range summary.ByType {
+		width = max(width, utf8.RuneCountInString(total.Type))
+	}
+
+	fmt.Fprintln(w, "\nSessions per type:")
+	for _, total := range summary.ByType {
+		padding := strings.Repeat(" ", width-utf8.RuneCountInString(total.Type))
+		fmt.Fprintf(w, "%s%s  %4d sessions  %s\n", total.Type, padding, total.Count, settings.formatDuration(total.Total))
+	}
+}
+
+func displayBootsPerWeek(w io.Writer, events []uptime.Event, window uptime.TimeWindow) {
+	weeks := uptime.BootsPerWeek(events, window)
+	if len(weeks) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "\nBoots per week:")
+	for _, week := range weeks {
+		fmt.Fprintf(w, "%d-W%02d: %s\n", week.Year, week.Week, plural(week.Boots, "boot"))
+	}
+}
+
+// formatDuration prints a duration like "2h 5m 10s". Spans of a day or
+// more print days and drop the seconds, e.g. "18d 0h 12m".
+func (settings renderSettings) formatDuration(d time.Duration) string {
+	// Clock skew can end a session before it starts
+	if d < 0 {
+		return "-" + settings.formatDuration(-d)
+	}
+	if settings.roundDurations > 0 {
+		d = d.Round(settings.roundDurations)
+	}
+	if d > 0 && d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+	seconds := int(d.Seconds()) % 60
+
+	units := []struct {
+		value  int
+		suffix string
+		size   time.Duration
+	}{{days, "d", 24 * time.Hour}, {hours, "h", time.Hour}, {minutes, "m", time.Minute}, {seconds, "s", time.Second}}
+
+	// Start at the largest non-zero unit, days replace the seconds
+	first, last := 3, 3
+	for i, unit := range units[:3] {
+		if unit.value > 0 {
+			first = i
+			break
+		}
+	}
+	if first == 0 {
+		last = 2
+	}
+	// Units below the rounding are always zero
+	for last > first && units[last].size < settings.roundDurations {
+		last--
+	}
+	if d == 0 && settings.roundDurations > time.Second {
+		// Zero in the unit rounded to, e.g. "0h"
+		for i, unit := range units {
+			if unit.size <= settings.roundDurations {
+				first, last = i, i
+				break
+			}
+		}
+	}
+
+	if settings.compactDurations {
+		for last > first && units[last].value == 0 {
+			last--
+		}
+	}
+
+	parts := []string{}
+	for _, unit := range units[first : last+1] {
+		parts = append(parts, fmt.Sprintf("%d%s", unit.value, unit.suffix))
+	}
+
+	if settings.compactDurations {
+		return strings.Join(parts, "")
+	}
+	return strings.Join(parts, " ")
+}
+
+func displayByDay(w io.Writer, sessions []uptime.Session, window uptime.TimeWindow, settings renderSettings) {
+	from, to := uptime.SessionsRange(sessions, window)
+
+	fmt.Fprintln(w, "Uptime per day:")
+	fmt.Fprintln(w)
+	for _, day := range uptime.UptimeByDay(sessions, from, to) {
+		fmt.Fprintf(w, "%s: %s\n", day.Day.Format("2006-01-02"), settings.formatDuration(day.Uptime))
+	}
+}
+
+// periodLabel names a rollup period like 2025-W44 or 2025-10
+func periodLabel(start time.Time, period string) string {
+	if period == "month" {
+		return start.Format("2006-01")
+	}
+	year, week := start.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+func displayRollup(w io.Writer, sessions []uptime.Session, period string, settings renderSettings) {
+	fmt.Fprintf(w, "Uptime per %s:\n", period)
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%-10s %16s %6s %8s %16s\n", "Period", "Uptime", "Boots", "Crashes", "Average session")
+	for _, rollup := range uptime.Rollup(sessions, period) {
+		fmt.Fprintf(w, "%-10s %16s %6d %8d %16s\n",
+			periodLabel(rollup.Start, period),
+			settings.formatDuration(rollup.Uptime),
+			rollup.Boots,
+			rollup.Crashes,
+			settings.formatDuration(rollup.Average),
+		)
+	}
+}
+
+// displayByBoot prints the sessions chronologically under a header for the
+// boot they belong to, so suspend cycles stay inside their boot
+func displayByBoot(w io.Writer, sessions []uptime.Session, settings renderSettings) {
+	fmt.Fprintln(w, "Sessions by boot:")
+
+	for i := 0; i < len(sessions); {
+		// Sessions of one boot are consecutive
+		j := i + 1
+		for j < len(sessions) && sessions[j].BootID == sessions[i].BootID {
+			j++
+		}
+		boot := sessions[i:j]
+
+		id := boot[0].BootID
+		if id == "" {
+			id = "(unknown)"
+		}
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "Boot %s: %s - %s\n", id,
+			boot[0].Start.Format(settings.timeLayout),
+			boot[len(boot)-1].End.Format(settings.timeLayout),
+		)
+		for _, session := range boot {
+			fmt.Fprintf(w, "    %s - %s  %-14s %s\n",
+				settings.formatTimestamp(session.Start),
+				settings.formatTimestamp(session.End),
+				settings.formatDuration(session.Duration),
+				session.Type,
+			)
+		}
+
+		i = j
+	}
+}
+
+// Width of the longest bar in the histogram
+const histogramWidth = 40
+
+func displayHistogram(w io.Writer, sessions []uptime.Session, boundaries []time.Duration) {
+	buckets := uptime.Histogram(sessions, boundaries)
+
+	labels := []string{}
+	maxCount, labelWidth := 0, 0
+	for _, bucket := range buckets {
+		var label string
+		switch {
+		case bucket.From == 0:
+			label = "<" + shortDuration(bucket.To)
+		case bucket.To == 0:
+			label = shortDuration(bucket.From) + "+"
+		default:
+			label = shortDuration(bucket.From) + "-" + shortDuration(bucket.To)
+		}
+		labels = append(labels, label)
+		maxCount = max(maxCount, bucket.Count)
+		labelWidth = max(labelWidth, len(label))
+	}
+
+	fmt.Fprintln(w, "Session durations:")
+	fmt.Fprintln(w)
+	for i, bucket := range buckets {
+		bar := 0
+		if maxCount > 0 {
+			bar = bucket.Count * histogramWidth / maxCount
+		}
+		line := fmt.Sprintf("%-*s %4d %s", labelWidth, labels[i], bucket.Count, strings.Repeat("#", bar))
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+}
+
+// shortDuration formats whole hours and minutes without the zero parts,
+// e.g. "1h" or "1h30m"
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+func displayAvailability(w io.Writer, sessions []uptime.Session, window uptime.TimeWindow, settings renderSettings) {
+	from, to := uptime.SessionsRange(sessions, window)
+	availability := uptime.CalculateAvailability(sessions, from, to)
+
+	fmt.Fprintln(w, "\n=== Availability ===")
+	fmt.Fprintf(w, "Period: %s - %s\n", from.Format(settings.shortTimeLayout), to.Format(settings.shortTimeLayout))
+	fmt.Fprintf(w, "Availability: %.2f%%\n", availability.Percent)
+	fmt.Fprintf(w, "Uptime: %s\n", settings.formatDuration(availability.Uptime))
+	fmt.Fprintf(w, "Downtime: %s (%s suspended)\n", settings.formatDuration(availability.Downtime), settings.formatDuration(availability.Suspended))
+	if availability.LongestGap > 0 {
+		fmt.Fprintf(w, "Longest downtime: %s (%s - %s)\n",
+			settings.formatDuration(availability.LongestGap),
+			availability.GapStart.Format(settings.shortTimeLayout),
+			availability.GapEnd.Format(settings.shortTimeLayout),
+		)
+	}
+}
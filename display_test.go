@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		settings renderSettings
+		expected string
+	}{
+		{0, renderSettings{}, "0s"},
+		{time.Millisecond, renderSettings{}, "1ms"},
+		{999 * time.Millisecond, renderSettings{}, "999ms"},
+		{time.Second, renderSettings{}, "1s"},
+		{59 * time.Second, renderSettings{}, "59s"},
+		{time.Minute, renderSettings{}, "1m 0s"},
+		{time.Hour - time.Second, renderSettings{}, "59m 59s"},
+		{time.Hour, renderSettings{}, "1h 0m 0s"},
+		{24*time.Hour - time.Second, renderSettings{}, "23h 59m 59s"},
+		{24 * time.Hour, renderSettings{}, "1d 0h 0m"},
+		{18*24*time.Hour + 12*time.Minute + 59*time.Second, renderSettings{}, "18d 0h 12m"},
+		{-500 * time.Millisecond, renderSettings{}, "-500ms"},
+		{-90 * time.Second, renderSettings{}, "-1m 30s"},
+
+		{time.Hour, renderSettings{compactDurations: true}, "1h"},
+		{time.Hour + 30*time.Second, renderSettings{compactDurations: true}, "1h0m30s"},
+		{30*24*time.Hour + 18*time.Hour, renderSettings{compactDurations: true}, "30d18h"},
+		{0, renderSettings{compactDurations: true}, "0s"},
+
+		{2*time.Hour + 29*time.Second, renderSettings{roundDurations: time.Minute}, "2h 0m"},
+		{59*time.Minute + 31*time.Second, renderSettings{roundDurations: time.Minute}, "1h 0m"},
+		{9*time.Hour + 3*time.Minute + 47*time.Second, renderSettings{roundDurations: time.Minute}, "9h 4m"},
+		{10 * time.Minute, renderSettings{roundDurations: time.Hour}, "0h"},
+		{90 * time.Minute, renderSettings{roundDurations: time.Hour, compactDurations: true}, "2h"},
+	}
+
+	for _, test := range tests {
+		if actual := test.settings.formatDuration(test.duration); actual != test.expected {
+			t.Errorf("formatDuration(%s) with %+v: expected %q, got %q", test.duration, test.settings, test.expected, actual)
+		}
+	}
+}
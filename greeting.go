@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/keskad/loco/uptime"
+)
+
+// displayGreeting prints a friendly headline about the current session,
+// e.g. "You've been up for 3h 12m since 09:05 this morning."
+func displayGreeting(w io.Writer, sessions []uptime.Session) {
+	current, asleep, ok := uptime.CurrentSession(sessions)
+	if !ok {
+		return
+	}
+
+	if asleep {
+		fmt.Fprintf(w, "You've been asleep since %s.\n", sinceWhen(current.End, now().In(current.End.Location())))
+		return
+	}
+	fmt.Fprintf(w, "You've been up for %s since %s.\n",
+		greetingDuration(current.Duration),
+		sinceWhen(current.Start, now().In(current.Start.Location())),
+	)
+}
+
+// sinceWhen describes t relative to now, like "09:05 this morning" or
+// "22:40 yesterday"
+func sinceWhen(t, now time.Time) string {
+	clock := t.Format("15:04")
+	today := uptime.StartOfDay(now)
+	switch {
+	case !t.Before(today):
+		switch {
+		case t.Hour() < 12:
+			return clock + " this morning"
+		case t.Hour() < 18:
+			return clock + " this afternoon"
+		default:
+			return clock + " this evening"
+		}
+	case !t.Before(today.AddDate(0, 0, -1)):
+		return clock + " yesterday"
+	case !t.Before(today.AddDate(0, 0, -6)):
+		return clock + " on " + t.Format("Monday")
+	default:
+		return t.Format("Jan 2, 15:04")
+	}
+}
+
+// greetingDuration prints a duration to the minute, like "3h 12m" or
+// "2d 5h"
+func greetingDuration(d time.Duration) string {
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"html/template"
+	"io"
+	"time"
+
+	"github.com/keskad/loco/uptime"
+)
+
+var htmlReport = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Uptime history</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; }
+th, td { padding: 0.3em 0.8em; border-bottom: 1px solid #ddd; text-align: left; }
+td.duration { text-align: right; }
+tr.crash td { color: #b00; }
+.chart { display: flex; align-items: flex-end; height: 120px; gap: 2px; margin: 1em 0; }
+.chart div { background: #4a8; width: 12px; }
+</style>
+</head>
+<body>
+<h1>Uptime history</h1>
+
+<h2>Uptime per day</h2>
+<div class="chart">
+{{- range .Days}}
+<div style="height: {{.Percent}}%" title="{{.Day}}: {{.Uptime}}"></div>
+{{- end}}
+</div>
+
+<h2>Sessions</h2>
+<table>
+<tr><th>Start</th><th>End</th><th>Uptime</th><th>Type</th></tr>
+{{- range .Sessions}}
+<tr{{if .Crash}} class="crash"{{end}}><td>{{.Start}}</td><td>{{.End}}</td><td class="duration">{{.Uptime}}</td><td>{{.Type}}</td></tr>
+{{- end}}
+</table>
+
+{{- with .Summary}}
+<h2>Summary</h2>
+<ul>
+<li>Number of sessions: {{.Count}}</li>
+<li>Total uptime: {{.Total}}</li>
+<li>Suspended time: {{.Suspended}}</li>
+<li>Hibernated time: {{.Hibernated}}</li>
+<li>Average session time: {{.Average}}</li>
+<li>Median session time: {{.Median}}</li>
+<li>Crashes: {{.Crashes}}</li>
+</ul>
+{{- end}}
+</body>
+</html>
+`))
+
+type htmlSession struct {
+	Start, End, Uptime, Type string
+	Crash                    bool
+}
+
+type htmlDay struct {
+	Day, Uptime string
+	Percent     int
+}
+
+type htmlSummary struct {
+	Count                                         int
+	Total, Suspended, Hibernated, Average, Median string
+	Crashes                                       int
+}
+
+// writeHTML writes a standalone page with the sessions newest first, a bar
+// per day and the summary. html/template escapes every value.
+func writeHTML(w io.Writer, sessions []uptime.Session, summary uptime.Summary, settings renderSettings) error {
+	data := struct {
+		Sessions []htmlSession
+		Days     []htmlDay
+		Summary  *htmlSummary
+	}{}
+
+	for i := len(sessions) - 1; i >= 0; i-- {
+		session := sessions[i]
+		data.Sessions = append(data.Sessions, htmlSession{
+			Start:  session.Start.Format(settings.timeLayout),
+			End:    session.End.Format(settings.timeLayout),
+			Uptime: settings.formatDuration(session.Duration),
+			Type:   session.Type,
+			Crash:  uptime.IsCrash(session),
+		})
+	}
+
+	if len(sessions) > 0 {
+		from, to := uptime.SessionsRange(sessions, uptime.TimeWindow{})
+		for _, day := range uptime.UptimeByDay(sessions, from, to) {
+			data.Days = append(data.Days, htmlDay{
+				Day:     day.Day.Format("2006-01-02"),
+				Uptime:  settings.formatDuration(day.Uptime),
+				Percent: int(day.Uptime * 100 / (24 * time.Hour)),
+			})
+		}
+	}
+
+	if summary.Count > 0 {
+		data.Summary = &htmlSummary{
+			Count:      summary.Count,
+			Total:      settings.formatDuration(summary.Total),
+			Suspended:  settings.formatDuration(summary.Suspended),
+			Hibernated: settings.formatDuration(summary.Hibernated),
+			Average:    settings.formatDuration(summary.Average),
+			Median:     settings.formatDuration(summary.Median),
+			Crashes:    summary.Crashes,
+		}
+	}
+
+	return htmlReport.Execute(w, data)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/keskad/loco/uptime"
+)
+
+// journalSocket is where journald accepts native protocol messages
+const journalSocket = "/run/systemd/journal/socket"
+
+// logToJournal writes the summary into the journal as one structured entry,
+// e.g. to be found with journalctl -t uptime-history SESSIONS=12
+func logToJournal(summary uptime.Summary, settings renderSettings) error {
+	fields := [][2]string{
+		{"MESSAGE", fmt.Sprintf("%d sessions, %s uptime, %d crashes",
+			summary.Count, settings.formatDuration(summary.Total), summary.Crashes)},
+		{"PRIORITY", "6"},
+		{"SYSLOG_IDENTIFIER", "uptime-history"},
+		{"SESSIONS", fmt.Sprint(summary.Count)},
+		{"TOTAL_UPTIME", fmt.Sprint(int64(summary.Total.Seconds()))},
+		{"SUSPENDED_TIME", fmt.Sprint(int64(summary.Suspended.Seconds()))},
+		{"CRASHES", fmt.Sprint(summary.Crashes)},
+	}
+
+	// None of the values contain a newline, so the simple KEY=value form
+	// of the protocol is enough
+	var message strings.Builder
+	for _, field := range fields {
+		fmt.Fprintf(&message, "%s=%s\n", field[0], field[1])
+	}
+
+	conn, err := net.Dial("unixgram", journalSocket)
+	if err != nil {
+		return fmt.Errorf("cannot log to the journal: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(message.String())); err != nil {
+		return fmt.Errorf("cannot log to the journal: %v", err)
+	}
+	return nil
+}
@@ -1,436 +1,737 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
-	"regexp"
+	"path/filepath"
 	"sort"
-	"strings"
+	"text/template"
 	"time"
-)
 
-type Event struct {
-	Timestamp time.Time
-	Type      string
-}
+	"github.com/keskad/loco/uptime"
+)
 
-type Session struct {
-	Start    time.Time
-	End      time.Time
-	Duration time.Duration
-	Type     string
+type options struct {
+	maxRows            int
+	outputFormat       string
+	outputFile         string
+	since              string
+	until              string
+	source             string
+	dedupWindow        time.Duration
+	byDay              bool
+	timeline           bool
+	timelineWidth      int
+	availability       bool
+	tz                 string
+	limit              int
+	limitSummary       bool
+	noColor            bool
+	dbPath             string
+	watch              watchInterval
+	types              []string
+	directory          string
+	machine            string
+	reason             bool
+	showDowntime       bool
+	reverse            bool
+	currentOnly        bool
+	fromJSON           string
+	groupByBoot        bool
+	boot               string
+	cache              bool
+	histogram          bool
+	buckets            []time.Duration
+	minDuration        time.Duration
+	minDurationSummary bool
+	logToJournal       bool
+	verify             bool
+	verifyTolerance    time.Duration
+	suspendWorkers     int
+	quiet              bool
+	template           *template.Template
+	mergeSuspends      bool
+	failOnCrash        bool
+	top                int
+	summaryJSON        string
+	minGap             time.Duration
+	noSuspend          bool
+	verbose            bool
+	compare            string
+	journalctl         string
+	events             bool
+	currentBootCutoff  time.Duration
+	greeting           bool
+	stdin              bool
+	anonymize          bool
+	anonymizeOffset    time.Duration
+	businessHours      *uptime.BusinessHours
+	percent            bool
+	rollup             string
+	splitOnSuspend     bool
+	render             renderSettings
 }
 
 func main() {
+	opts := options{render: defaultRenderSettings()}
+
 	// Parse command-line flags
-	maxRows := flag.Int("rows", 20, "Number of rows to display in the table")
+	flag.IntVar(&opts.maxRows, "rows", 20, "Number of rows to display in the table")
+	jsonOutput := flag.Bool("json", false, "Print sessions and summary as JSON instead of a table")
+	templateOutput := flag.String("format", "", "Print each session with this Go template, e.g. '{{.Start.Format \"15:04\"}} {{.DurationText}} {{.Type}}'")
+	flag.BoolVar(&opts.render.prettyJSON, "json-pretty", false, "Like --json, indented for reading")
+	ndjsonOutput := flag.Bool("ndjson", false, "Print one JSON object per session and line instead of a table")
+	csvOutput := flag.Bool("csv", false, "Print sessions as CSV instead of a table")
+	prometheusOutput := flag.Bool("prometheus", false, "Print metrics in the Prometheus text format instead of a table")
+	markdownOutput := flag.Bool("markdown", false, "Print sessions and summary as a Markdown table instead of a table")
+	htmlOutput := flag.Bool("html", false, "Print a standalone HTML page with the sessions, a daily uptime chart and the summary")
+	flag.StringVar(&opts.outputFile, "output", "", "Write the report to this file instead of stdout")
+	flag.StringVar(&opts.outputFile, "o", "", "Shorthand for --output")
+	flag.StringVar(&opts.summaryJSON, "summary-json", "", "Also write the summary as JSON to this file")
+	flag.StringVar(&opts.since, "since", "", "Only include uptime after this date (2025-01-02) or relative time (7d, 24h)")
+	flag.StringVar(&opts.until, "until", "", "Only include uptime before this date (2025-01-02) or relative time (7d, 24h)")
+	flag.StringVar(&opts.source, "source", "auto", "Where to read events from: journal, wtmp, pmset, eventlog or auto")
+	flag.DurationVar(&opts.dedupWindow, "dedup-window", 2*time.Minute, "Merge repeated events of the same type closer than this, unless they belong to different boots")
+	flag.BoolVar(&opts.byDay, "by-day", false, "Print total uptime per calendar day instead of the session table")
+	flag.BoolVar(&opts.groupByBoot, "group-by-boot", false, "Print sessions grouped under the boot they belong to instead of the session table")
+	flag.BoolVar(&opts.histogram, "histogram", false, "Print how many sessions fall into each duration bucket instead of the session table")
+	histogramBuckets := flag.String("histogram-buckets", "1h,4h,8h", "Comma-separated boundaries of the --histogram buckets")
+	flag.StringVar(&opts.rollup, "rollup", "", "Print total uptime, boots, crashes and average session per week or month instead of the session table")
+	flag.IntVar(&opts.top, "top", 0, "Print the N longest sessions, longest first, instead of the session table")
+	flag.BoolVar(&opts.timeline, "timeline", false, "Draw a bar per day showing when the machine was up instead of the session table")
+	flag.IntVar(&opts.timelineWidth, "timeline-width", 24, "Number of cells in a --timeline bar")
+	flag.BoolVar(&opts.availability, "availability", false, "Also print the share of the --since/--until period the machine was up")
+	flag.StringVar(&opts.tz, "tz", "", "Display timestamps in this time zone, e.g. UTC or America/New_York (default local time)")
+	flag.IntVar(&opts.limit, "limit", 0, "Keep only the N most recent sessions (default unlimited)")
+	flag.BoolVar(&opts.limitSummary, "limit-summary", false, "Compute the summary over the --limit sessions only instead of all of them")
+	flag.BoolVar(&opts.quiet, "quiet", false, "Print only the summary, with --json only the summary object")
+	flag.BoolVar(&opts.quiet, "summary-only", false, "Same as --quiet")
+	flag.StringVar(&opts.render.timeLayout, "time-format", opts.render.timeLayout, "Layout of displayed timestamps as Go reference time, e.g. \"Jan _2 3:04PM\"")
+	flag.BoolVar(&opts.render.groupByType, "group-by-type", false, "Add the number and uptime of the sessions per type, e.g. resume → suspend, to the summary")
+	flag.BoolVar(&opts.percent, "percent", false, "Add a column with each session's percentage of the total uptime to the table")
+	flag.BoolVar(&opts.render.showBootID, "show-boot-id", false, "Add the first 8 characters of the boot ID to each session in the table")
+	flag.BoolVar(&opts.render.relativeTimes, "relative", false, "Show session start and end in the table like \"3 days ago\"")
+	flag.BoolVar(&opts.render.compactDurations, "compact", false, "Print durations like \"30d18h\" without spaces and trailing zero units")
+	flag.DurationVar(&opts.render.roundDurations, "round", 0, "Round printed durations to this unit, e.g. 1m or 1h")
+	flag.DurationVar(&opts.minDuration, "min-duration", 0, "Hide sessions shorter than this, e.g. 5m")
+	flag.BoolVar(&opts.minDurationSummary, "min-duration-summary", false, "Also leave the sessions hidden by --min-duration out of the summary")
+	flag.BoolVar(&opts.noColor, "no-color", false, "Disable colored output")
+	flag.StringVar(&opts.dbPath, "db", "", "Keep session history in this SQLite database and merge it into the report")
+	flag.StringVar(&opts.journalctl, "journalctl", "", "Path of the journalctl executable (default journalctl from PATH)")
+	flag.StringVar(&opts.directory, "directory", "", "Read an exported journal from this directory instead of the system journal, a glob like /srv/journals/* reads one per machine")
+	flag.DurationVar(&opts.currentBootCutoff, "current-boot-cutoff", time.Minute, "With --directory, the last boot counts as still running if its last entry is at most this old")
+	flag.StringVar(&opts.boot, "boot", "", "Only read this boot, as an offset like journalctl -b (0 current, -1 previous) or a boot ID")
+	flag.StringVar(&opts.machine, "machine", "", "Read the journal of this local container")
+	flag.BoolVar(&opts.reason, "reason", false, "Look up who requested each shutdown and whether the battery ran out, e.g. \"boot → shutdown (user:root)\"")
+	flag.DurationVar(&opts.minGap, "min-gap", 0, "Merge suspends shorter than this into the surrounding session, e.g. 1m")
+	flag.BoolVar(&opts.mergeSuspends, "merge-suspends", false, "Show each boot as one session annotated with its suspends instead of a row per suspend cycle")
+	flag.BoolVar(&opts.splitOnSuspend, "split-on-suspend", false, "Start a new session at every resume, which is the default; rejects --merge-suspends and --min-gap")
+	flag.BoolVar(&opts.showDowntime, "show-downtime", false, "Insert \"shutdown → boot (off)\" rows for the time the machine was powered off")
+	flag.BoolVar(&opts.reverse, "reverse", false, "List sessions newest first in JSON, NDJSON, CSV and --format output (the table and Markdown always do)")
+	flag.BoolVar(&opts.anonymize, "anonymize", false, "Move all dates by a random number of weeks and leave out boot IDs and host names, for sharing the output")
+	flag.BoolVar(&opts.greeting, "greeting", false, "Start the report with a friendly line about the current session")
+	flag.BoolVar(&opts.currentOnly, "current-only", false, "Print only how long the machine has been up right now")
+	flag.StringVar(&opts.compare, "compare", "", "Show how the summary changed since a saved --json report")
+	flag.BoolVar(&opts.events, "events", false, "Print the boot, shutdown, suspend and resume events instead of the sessions calculated from them")
+	flag.BoolVar(&opts.stdin, "stdin", false, "Read journalctl --list-boots output and journal lines from stdin instead of running journalctl, see the README")
+	flag.StringVar(&opts.fromJSON, "from-json", "", "Render sessions from a saved --json report (- for stdin) instead of reading the system events")
+	businessHours := flag.String("business-hours", "", "Count only uptime within these hours, e.g. \"09:00-18:00\" or \"Mon-Fri 09:00-18:00\"")
+	typeFilter := flag.String("type", "", "Only show sessions starting or ending with these events, e.g. suspend,hibernate")
+	flag.BoolVar(&opts.noSuspend, "no-suspend", false, "Skip suspend and hibernate events and report boot to shutdown sessions only")
+	flag.IntVar(&opts.suspendWorkers, "suspend-workers", 0, "Query suspend events per boot with this many concurrent journalctl calls (default one query for the whole journal)")
+	flag.BoolVar(&opts.verbose, "verbose", false, "Log each step and how long it took to stderr")
+	flag.BoolVar(&opts.cache, "cache", false, "Cache what the journal says about ended boots, so repeated runs and --watch only read newer ones")
+	flag.BoolVar(&opts.logToJournal, "log-to-journal", false, "Also write the summary into the journal as a structured entry tagged uptime-history")
+	flag.BoolVar(&opts.verify, "verify", false, "Compare the time since the last boot with the kernel uptime and warn if they differ")
+	flag.DurationVar(&opts.verifyTolerance, "verify-tolerance", time.Minute, "Largest difference --verify accepts")
+	flag.BoolVar(&opts.failOnCrash, "fail-on-crash", false, "Exit with code 3 if a crash or failed suspend is found in the reported range")
+	showVersion := flag.Bool("version", false, "Print the version and exit")
+	nowOverride := flag.String("now", "", "Pretend the current time is this RFC 3339 timestamp, for debugging")
+	flag.Var(&opts.watch, "watch", "Refresh the report periodically, optionally with an interval like --watch=30s (default 60s)")
+
+	// Config file values become the new defaults, then the environment
+	// overrides them and command line flags win
+	if path, err := configPath(); err == nil {
+		if err := applyConfigFile(flag.CommandLine, path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+	}
+	if err := applyEnvironment(flag.CommandLine); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	beforeCommandLine := setFlags(flag.CommandLine)
 	flag.Parse()
+	preferCommandLineFormat(flag.CommandLine, beforeCommandLine)
 
-	fmt.Println("=== Computer Boot and Shutdown History ===")
-	fmt.Println()
+	if *showVersion {
+		fmt.Println(versionString())
+		return
+	}
 
-	events, err := getSystemEvents()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	if isFlagSet("limit") && opts.limit <= 0 {
+		fmt.Fprintf(os.Stderr, "Error: --limit must be a positive number, got %d\n", opts.limit)
+		flag.Usage()
+		os.Exit(2)
 	}
 
-	if len(events) == 0 {
-		fmt.Println("No system events found.")
-		return
+	if opts.suspendWorkers < 0 {
+		fmt.Fprintf(os.Stderr, "Error: --suspend-workers must not be negative, got %d\n", opts.suspendWorkers)
+		os.Exit(2)
 	}
 
-	sessions := calculateSessions(events)
+	if opts.top < 0 {
+		fmt.Fprintf(os.Stderr, "Error: --top must be a positive number, got %d\n", opts.top)
+		os.Exit(2)
+	}
 
-	if len(sessions) == 0 {
-		fmt.Println("Cannot calculate work sessions.")
-		return
+	if opts.render.roundDurations < 0 {
+		fmt.Fprintf(os.Stderr, "Error: --round must not be negative, got %s\n", opts.render.roundDurations)
+		os.Exit(2)
 	}
 
-	displaySessions(sessions, *maxRows)
-	displaySummary(sessions)
-}
+	if opts.timelineWidth <= 0 {
+		fmt.Fprintf(os.Stderr, "Error: --timeline-width must be a positive number, got %d\n", opts.timelineWidth)
+		os.Exit(2)
+	}
 
-func getSystemEvents() ([]Event, error) {
-	// First, get the list of all boots with timestamps
-	bootCmd := exec.Command("journalctl", "--list-boots", "--no-pager", "--output=short-iso")
-	bootOutput, err := bootCmd.Output()
+	loc, err := loadLocation(opts.tz)
 	if err != nil {
-		return nil, fmt.Errorf("cannot read boot list: %v", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
 	}
 
-	events := []Event{}
+	if *nowOverride != "" {
+		fixed, err := time.Parse(time.RFC3339, *nowOverride)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --now value: %v\n", err)
+			os.Exit(2)
+		}
+		now = func() time.Time { return fixed }
+	}
+
+	if isFlagSet("time-format") {
+		if err := validateTimeLayout(opts.render.timeLayout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --time-format value: %v\n", err)
+			os.Exit(2)
+		}
+		opts.render.shortTimeLayout = opts.render.timeLayout
+	}
 
-	// Parse each boot from --list-boots
-	bootScanner := bufio.NewScanner(strings.NewReader(string(bootOutput)))
-	bootScanner.Scan() // Skip header
+	if _, err := uptime.NewTimeWindow(opts.since, opts.until, now(), loc); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
 
-	bootInfos := []struct {
-		ID        string
-		StartTime time.Time
-		EndTime   time.Time
-	}{}
+	if err := uptime.ValidateSource(opts.source); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
 
-	for bootScanner.Scan() {
-		line := bootScanner.Text()
-		// Format: IDX BOOT_ID FIRST_ENTRY LAST_ENTRY
-		// Example: -10 3460c36536374bb48bb910bae80c34b6 Tue 2025-10-28 16:28:42 CET Thu 2025-10-30 00:14:40 CET
+	if opts.types, err = uptime.ParseEventTypes(*typeFilter); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid --type value: %v\n", err)
+		os.Exit(2)
+	}
 
-		parts := strings.SplitN(line, " ", 3)
-		if len(parts) < 3 {
-			continue
+	if *businessHours != "" {
+		hours, err := uptime.ParseBusinessHours(*businessHours)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --business-hours value: %v\n", err)
+			os.Exit(2)
 		}
+		opts.businessHours = &hours
+	}
 
-		bootID := parts[0]
+	if opts.buckets, err = uptime.ParseBuckets(*histogramBuckets); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid --histogram-buckets value: %v\n", err)
+		os.Exit(2)
+	}
 
-		// Find separator between dates (usually "—" or several spaces)
-		// We're looking for pattern: date + time + timezone, then next date
-		dateRegex := regexp.MustCompile(`(\w{3} \d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2} \w+)`)
-		dates := dateRegex.FindAllString(line, -1)
+	if *templateOutput != "" {
+		if opts.template, err = template.New("format").Parse(*templateOutput); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --format template: %v\n", err)
+			os.Exit(2)
+		}
+	}
 
-		if len(dates) >= 2 {
-			// Parse start time
-			startTime, err := time.Parse("Mon 2006-01-02 15:04:05 MST", dates[0])
-			if err != nil {
-				continue
-			}
+	switch {
+	case opts.template != nil:
+		opts.outputFormat = "template"
+	case *jsonOutput, opts.render.prettyJSON:
+		opts.outputFormat = "json"
+	case *ndjsonOutput:
+		opts.outputFormat = "ndjson"
+	case *csvOutput:
+		opts.outputFormat = "csv"
+	case *prometheusOutput:
+		opts.outputFormat = "prometheus"
+	case *markdownOutput:
+		opts.outputFormat = "markdown"
+	case *htmlOutput:
+		opts.outputFormat = "html"
+	}
 
-			// Parse end time
-			endTime, err := time.Parse("Mon 2006-01-02 15:04:05 MST", dates[1])
-			if err != nil {
-				continue
-			}
+	if opts.quiet && opts.outputFormat != "" && opts.outputFormat != "json" {
+		fmt.Fprintf(os.Stderr, "Error: --quiet works with the table and --json only\n")
+		os.Exit(2)
+	}
 
-			bootInfos = append(bootInfos, struct {
-				ID        string
-				StartTime time.Time
-				EndTime   time.Time
-			}{
-				ID:        bootID,
-				StartTime: startTime,
-				EndTime:   endTime,
-			})
-
-			// Add boot event
-			events = append(events, Event{
-				Timestamp: startTime,
-				Type:      "boot",
-			})
-
-			// Add shutdown event (if boot has ended)
-			// Check if this is not the current boot
-			if endTime.Before(time.Now().Add(-1 * time.Minute)) {
-				events = append(events, Event{
-					Timestamp: endTime,
-					Type:      "shutdown",
-				})
-			}
+	if opts.rollup != "" {
+		if err := uptime.ValidatePeriod(opts.rollup); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --rollup value: %v\n", err)
+			os.Exit(2)
+		}
+		if opts.outputFormat != "" && opts.outputFormat != "json" {
+			fmt.Fprintf(os.Stderr, "Error: --rollup works with the table and --json only\n")
+			os.Exit(2)
 		}
 	}
 
-	// Now try to detect suspend/resume for all boots
-	suspendEvents := detectSuspendResume("")
-	events = append(events, suspendEvents...)
+	if opts.stdin && (opts.fromJSON != "" || opts.directory != "" || opts.machine != "" || opts.boot != "" || opts.watch > 0) {
+		fmt.Fprintf(os.Stderr, "Error: --stdin cannot be combined with --from-json, --directory, --machine, --boot or --watch\n")
+		os.Exit(2)
+	}
 
-	// Sort chronologically
-	sort.Slice(events, func(i, j int) bool {
-		return events[i].Timestamp.Before(events[j].Timestamp)
-	})
+	if opts.anonymize {
+		if opts.render.relativeTimes {
+			fmt.Fprintf(os.Stderr, "Error: --relative would reveal the real dates hidden by --anonymize\n")
+			os.Exit(2)
+		}
+		// The same offset for every refresh of --watch
+		opts.anonymizeOffset = anonymizeOffset()
+	}
 
-	// Remove duplicates
-	events = deduplicateEvents(events)
+	if opts.events {
+		if opts.fromJSON != "" {
+			fmt.Fprintf(os.Stderr, "Error: --events cannot be used with --from-json, the report has no events\n")
+			os.Exit(2)
+		}
+		if opts.outputFormat != "" && opts.outputFormat != "json" {
+			fmt.Fprintf(os.Stderr, "Error: --events works with the table and --json only\n")
+			os.Exit(2)
+		}
+	}
 
-	return events, nil
-}
+	if opts.splitOnSuspend && (opts.mergeSuspends || opts.minGap > 0) {
+		fmt.Fprintf(os.Stderr, "Error: --split-on-suspend cannot be combined with --merge-suspends or --min-gap\n")
+		os.Exit(2)
+	}
 
-func detectSuspendResume(bootID string) []Event {
-	events := []Event{}
+	if opts.compare != "" && opts.outputFormat != "" {
+		fmt.Fprintf(os.Stderr, "Error: --compare works with the table only\n")
+		os.Exit(2)
+	}
 
-	timestampRegex := regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}[+-]\d{2}:\d{2})`)
+	if opts.watch > 0 {
+		watch(opts)
+		return
+	}
 
-	// Use journalctl to find suspend events
-	var cmd *exec.Cmd
-	if bootID == "" {
-		cmd = exec.Command("journalctl", "--no-pager", "-o", "short-iso", "-u", "systemd-suspend.service")
-	} else {
-		cmd = exec.Command("journalctl", "-b", bootID, "--no-pager", "-o", "short-iso", "-u", "systemd-suspend.service")
+	err = report(context.Background(), opts)
+	if errors.Is(err, errCrashDetected) {
+		os.Exit(exitCrashDetected)
 	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
 
-	output, err := cmd.CombinedOutput()
+// now returns the current time, replaced by --now
+var now = time.Now
 
-	if err == nil && len(output) > 0 {
-		scanner := bufio.NewScanner(strings.NewReader(string(output)))
+// exitCrashDetected is the exit code for --fail-on-crash, see the README
+const exitCrashDetected = 3
 
-		for scanner.Scan() {
-			line := scanner.Text()
+// errCrashDetected is returned by report when --fail-on-crash finds a crash
+var errCrashDetected = errors.New("crash detected")
 
-			// Filter only lines with "System Suspend"
-			if !strings.Contains(line, "System Suspend") {
-				continue
-			}
+// report reads the events and prints the report selected by the options.
+// Options are expected to be validated already. Cancelling ctx kills the
+// commands reading the events.
+func report(ctx context.Context, opts options) (err error) {
+	loc, err := loadLocation(opts.tz)
+	if err != nil {
+		return err
+	}
 
-			matches := timestampRegex.FindStringSubmatch(line)
-			if len(matches) < 2 {
-				continue
-			}
+	// Relative bounds like "7d" move along with the clock, so the window is
+	// computed on every run
+	window, err := uptime.NewTimeWindow(opts.since, opts.until, now(), loc)
+	if err != nil {
+		return err
+	}
 
-			timestampStr := matches[1]
-			timestamp, err := time.Parse("2006-01-02T15:04:05-07:00", timestampStr)
-			if err != nil {
-				continue
-			}
+	var w io.Writer = os.Stdout
+	if opts.outputFile != "" {
+		file, err := os.Create(opts.outputFile)
+		if err != nil {
+			return fmt.Errorf("cannot create output file: %v", err)
+		}
+		defer file.Close()
+		w = file
+	}
 
-			eventType := ""
+	if opts.outputFormat == "" && !opts.currentOnly && !opts.quiet {
+		fmt.Fprintln(w, "=== Computer Boot and Shutdown History ===")
+		fmt.Fprintln(w)
+	}
 
-			// Suspend - "Starting System Suspend"
-			if strings.Contains(line, "Starting System Suspend") {
-				eventType = "suspend"
-			}
+	events, sessions, err := readSessions(ctx, opts, window, loc)
+	if err != nil {
+		return err
+	}
+	if opts.events {
+		if opts.anonymize {
+			events = newAnonymizer(opts.anonymizeOffset, events, nil).events(events)
+		}
+		if opts.outputFormat == "json" {
+			return writeEventsJSON(w, events, opts.render)
+		}
+		displayEvents(w, events, opts.render)
+		return nil
+	}
 
-			// Resume - "Finished System Suspend"
-			if strings.Contains(line, "Finished System Suspend") {
-				eventType = "resume"
-			}
+	sessions, warnings := uptime.RepairSessions(sessions)
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
 
-			if eventType != "" {
-				events = append(events, Event{
-					Timestamp: timestamp,
-					Type:      eventType,
-				})
-			}
-		}
+	// --verify needs the real start of the last boot, even before --since
+	unclipped := sessions
+	sessions = uptime.ConvertSessions(uptime.ClipSessions(sessions, window), loc)
+
+	// A report ending in the past does not know what the machine does now
+	reachesNow := window.ReachesNow(now())
+	if !reachesNow {
+		sessions = uptime.EndAtWindow(sessions)
 	}
 
-	// Check hibernate too
-	if bootID == "" {
-		cmd = exec.Command("journalctl", "--no-pager", "-o", "short-iso", "-u", "systemd-hibernate.service")
-	} else {
-		cmd = exec.Command("journalctl", "-b", bootID, "--no-pager", "-o", "short-iso", "-u", "systemd-hibernate.service")
+	// Clipping recomputes durations from start and end, which would count
+	// the merged sleeps as uptime
+	sessions = uptime.MergeShortSuspends(sessions, opts.minGap)
+
+	// The current session is looked up before any filtering hides it
+	allSessions := sessions
+	sessions = uptime.FilterSessionsByType(sessions, opts.types)
+	if opts.businessHours != nil {
+		sessions = uptime.ClipToBusinessHours(sessions, *opts.businessHours)
 	}
 
-	output, err = cmd.CombinedOutput()
-	if err == nil && len(output) > 0 {
-		scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	// Everything is selected by the real dates, only what is printed moves
+	today := now()
+	if opts.anonymize {
+		today = today.Add(opts.anonymizeOffset)
+		anonymizer := newAnonymizer(opts.anonymizeOffset, events, allSessions)
+		events = anonymizer.events(events)
+		allSessions = anonymizer.sessions(allSessions)
+		sessions = anonymizer.sessions(sessions)
+		window = anonymizer.window(window)
+	}
 
-		for scanner.Scan() {
-			line := scanner.Text()
+	if opts.currentOnly {
+		displayCurrent(w, allSessions, opts.render)
+		return nil
+	}
 
-			// Filter only lines with "System Hibernate"
-			if !strings.Contains(line, "System Hibernate") {
-				continue
-			}
+	// The summary counts the rows as they are printed
+	if opts.mergeSuspends {
+		sessions = uptime.MergeSuspends(sessions)
+	}
 
-			matches := timestampRegex.FindStringSubmatch(line)
-			if len(matches) < 2 {
-				continue
-			}
+	// Short sessions are hidden, but still counted unless asked otherwise
+	if opts.minDurationSummary {
+		sessions = uptime.FilterSessionsByDuration(sessions, opts.minDuration)
+	}
 
-			timestampStr := matches[1]
-			timestamp, err := time.Parse("2006-01-02T15:04:05-07:00", timestampStr)
-			if err != nil {
-				continue
+	summary := uptime.SummarizeAt(sessions, today)
+	if opts.failOnCrash && summary.Crashes+summary.FailedSuspends > 0 {
+		// Reported once the output is complete
+		defer func() {
+			if err == nil {
+				err = errCrashDetected
 			}
+		}()
+	}
 
-			eventType := ""
+	if opts.mergeSuspends {
+		sessions = annotateSuspends(sessions, opts.render)
+	}
+	sessions = uptime.FilterSessionsByDuration(sessions, opts.minDuration)
+	sessions = uptime.LimitSessions(sessions, opts.limit)
+	if opts.limitSummary {
+		summary = uptime.SummarizeAt(sessions, today)
+	}
 
-			// Hibernate
-			if strings.Contains(line, "Starting System Hibernate") {
-				eventType = "hibernate"
-			}
+	if opts.summaryJSON != "" {
+		if err := writeSummaryFile(opts.summaryJSON, summary, opts.render); err != nil {
+			return err
+		}
+	}
 
-			// Wake from hibernate
-			if strings.Contains(line, "Finished System Hibernate") {
-				eventType = "resume"
-			}
+	if opts.logToJournal {
+		if err := logToJournal(summary, opts.render); err != nil {
+			return err
+		}
+	}
 
-			if eventType != "" {
-				events = append(events, Event{
-					Timestamp: timestamp,
-					Type:      eventType,
-				})
-			}
+	// In machine-readable modes the output must contain nothing but the document
+	if opts.outputFormat != "" {
+		if opts.quiet {
+			return writeJSONSummary(w, summary, opts.render)
+		}
+		if opts.rollup != "" {
+			return writeRollupJSON(w, sessions, opts.rollup, opts.render)
 		}
+		// Markdown and HTML are rendered newest first like the table already
+		if opts.reverse && opts.outputFormat != "markdown" && opts.outputFormat != "html" {
+			sessions = uptime.ReverseSessions(sessions)
+		}
+		if opts.outputFormat == "template" {
+			return writeTemplate(w, opts.template, sessions, opts.render)
+		}
+		return writeMachineOutput(w, opts.outputFormat, sessions, summary, opts.render)
 	}
 
-	return events
-}
+	// The previous report is summarized like the current one
+	var previous *uptime.Summary
+	if opts.compare != "" {
+		sessions, err := readJSONFile(opts.compare)
+		if err != nil {
+			return err
+		}
+		summary := uptime.SummarizeAt(sessions, now())
+		previous = &summary
+	}
 
-func deduplicateEvents(events []Event) []Event {
-	if len(events) == 0 {
-		return events
+	if opts.quiet {
+		displaySummary(w, summary, opts.render)
+		if opts.render.groupByType {
+			displayByType(w, summary, opts.render)
+		}
+		if previous != nil {
+			displayComparison(w, summary, *previous, opts.compare, opts.render)
+		}
+		return nil
 	}
 
-	result := []Event{events[0]}
+	if len(events) == 0 && opts.fromJSON == "" {
+		fmt.Fprintln(w, "No system events found.")
+		return nil
+	}
 
-	for i := 1; i < len(events); i++ {
-		lastEvent := result[len(result)-1]
-		currentEvent := events[i]
+	if len(sessions) == 0 {
+		fmt.Fprintln(w, "Cannot calculate work sessions.")
+		return nil
+	}
 
-		// If the same event within 2 minutes, ignore
-		if currentEvent.Type == lastEvent.Type &&
-			currentEvent.Timestamp.Sub(lastEvent.Timestamp) < 2*time.Minute {
-			continue
-		}
+	if opts.byDay {
+		displayByDay(w, sessions, window, opts.render)
+		return nil
+	}
 
-		result = append(result, currentEvent)
+	if opts.rollup != "" {
+		displayRollup(w, sessions, opts.rollup, opts.render)
+		return nil
 	}
 
-	return result
-}
+	if opts.groupByBoot {
+		displayByBoot(w, sessions, opts.render)
+		return nil
+	}
 
-func calculateSessions(events []Event) []Session {
-	sessions := []Session{}
-
-	var sessionStart *Event
-	var sessionType string
-
-	for i := 0; i < len(events); i++ {
-		event := events[i]
-
-		switch event.Type {
-		case "boot", "resume":
-			// A new activity session begins
-			if sessionStart != nil {
-				// Close previous session (was improperly terminated)
-				sessions = append(sessions, Session{
-					Start:    sessionStart.Timestamp,
-					End:      event.Timestamp,
-					Duration: event.Timestamp.Sub(sessionStart.Timestamp),
-					Type:     sessionType + " → " + event.Type,
-				})
-			}
-			sessionStart = &event
-			if event.Type == "boot" {
-				sessionType = "boot"
-			} else {
-				sessionType = "resume"
-			}
+	if opts.histogram {
+		displayHistogram(w, sessions, opts.buckets)
+		return nil
+	}
 
-		case "shutdown", "suspend", "hibernate":
-			// Activity session ends
-			if sessionStart != nil {
-				endType := ""
-				switch event.Type {
-				case "shutdown":
-					endType = "shutdown"
-				case "suspend":
-					endType = "suspend"
-				case "hibernate":
-					endType = "hibernate"
-				}
-
-				sessions = append(sessions, Session{
-					Start:    sessionStart.Timestamp,
-					End:      event.Timestamp,
-					Duration: event.Timestamp.Sub(sessionStart.Timestamp),
-					Type:     sessionType + " → " + endType,
-				})
-				sessionStart = nil
-				sessionType = ""
-			}
-		}
+	if opts.timeline {
+		displayTimeline(w, sessions, window, opts.timelineWidth)
+		return nil
 	}
 
-	// If there's an open session, mark as "still active"
-	if sessionStart != nil {
-		now := time.Now()
-		sessions = append(sessions, Session{
-			Start:    sessionStart.Timestamp,
-			End:      now,
-			Duration: now.Sub(sessionStart.Timestamp),
-			Type:     sessionType + " → (still active)",
-		})
+	// Files get the plain, classic layout
+	width, useColor := 0, false
+	if opts.outputFile == "" {
+		width, useColor = terminalWidth(), colorEnabled(opts.noColor)
 	}
 
-	return sessions
-}
+	// The share is of the total in the summary
+	total := time.Duration(0)
+	if opts.percent {
+		total = summary.Total
+	}
+
+	if opts.top > 0 {
+		displayTop(w, sessions, opts.top, width, useColor, total, opts.render)
+		return nil
+	}
 
-func displaySessions(sessions []Session, maxRows int) {
-	fmt.Println("Computer work sessions:")
-	fmt.Println()
-	fmt.Printf("%-25s | %-25s | %-20s | %s\n", "Start", "End", "Uptime", "Type")
-	fmt.Println(strings.Repeat("-", 110))
+	// Downtime rows are shown in the table only, the summary counts uptime
+	rows := sessions
+	if opts.showDowntime {
+		rows = uptime.InsertDowntime(sessions)
+	}
 
-	// Determine how many rows to display
-	displayCount := len(sessions)
-	if maxRows > 0 && maxRows < displayCount {
-		displayCount = maxRows
+	if reachesNow {
+		if opts.greeting {
+			displayGreeting(w, allSessions)
+		}
+		displayCurrent(w, allSessions, opts.render)
+		fmt.Fprintln(w)
+	}
+	displaySessions(w, rows, opts.maxRows, width, useColor, total, opts.render)
+	displaySummary(w, summary, opts.render)
+	if opts.render.groupByType {
+		displayByType(w, summary, opts.render)
 	}
+	if previous != nil {
+		displayComparison(w, summary, *previous, opts.compare, opts.render)
+	}
+	displayHosts(w, sessions, opts.render)
+	displayBootsPerWeek(w, events, window)
 
-	// Display the last N sessions in reverse order (newest first)
-	startIdx := len(sessions) - displayCount
-	for i := len(sessions) - 1; i >= startIdx; i-- {
-		session := sessions[i]
-		fmt.Printf("%-25s | %-25s | %-20s | %s\n",
-			session.Start.Format("2006-01-02 15:04:05"),
-			session.End.Format("2006-01-02 15:04:05"),
-			formatDuration(session.Duration),
-			session.Type,
-		)
+	if opts.availability {
+		displayAvailability(w, sessions, window, opts.render)
 	}
 
-	if displayCount < len(sessions) {
-		fmt.Printf("\n(Showing last %d of %d sessions. Use -rows flag to show more)\n", displayCount, len(sessions))
+	if opts.verify {
+		return verifyUptime(w, unclipped, opts.verifyTolerance, opts.render)
 	}
-	fmt.Println()
+
+	return nil
 }
 
-func displaySummary(sessions []Session) {
-	if len(sessions) == 0 {
-		return
+// readSessions reconstructs the sessions from the system events, or loads
+// them from a saved --json report
+func readSessions(ctx context.Context, opts options, window uptime.TimeWindow, loc *time.Location) ([]uptime.Event, []uptime.Session, error) {
+	if opts.fromJSON != "" {
+		sessions, err := readJSONFile(opts.fromJSON)
+		return nil, sessions, err
 	}
 
-	totalDuration := time.Duration(0)
-	for _, session := range sessions {
-		totalDuration += session.Duration
+	var logf func(format string, args ...any)
+	if opts.verbose {
+		started := time.Now()
+		logf = func(format string, args ...any) {
+			fmt.Fprintf(os.Stderr, "[%6.3fs] %s\n", time.Since(started).Seconds(), fmt.Sprintf(format, args...))
+		}
 	}
 
-	avgDuration := totalDuration / time.Duration(len(sessions))
+	var input io.Reader
+	if opts.stdin {
+		input = os.Stdin
+	}
 
-	fmt.Println("\n=== Summary ===")
-	fmt.Printf("Number of sessions: %d\n", len(sessions))
-	fmt.Printf("Total uptime: %s\n", formatDuration(totalDuration))
-	fmt.Printf("Average session time: %s\n", formatDuration(avgDuration))
+	journalCache := ""
+	if opts.cache {
+		path, err := cachePath()
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot locate the cache directory: %v", err)
+		}
+		journalCache = path
+	}
 
-	// Longest and shortest session
-	var longest, shortest Session
-	if len(sessions) > 0 {
-		longest = sessions[0]
-		shortest = sessions[0]
+	directories, err := journalDirectories(opts.directory)
+	if err != nil {
+		return nil, nil, err
+	}
 
-		for _, session := range sessions[1:] {
-			if session.Duration > longest.Duration {
-				longest = session
-			}
-			if session.Duration < shortest.Duration {
-				shortest = session
+	// Every machine has its own history, so its sessions are calculated on
+	// their own and merged afterwards
+	allEvents, allSessions := []uptime.Event{}, []uptime.Session{}
+	for _, directory := range directories {
+		events, err := uptime.GetSystemEvents(uptime.Config{
+			Source:      opts.source,
+			Context:     ctx,
+			DedupWindow: opts.dedupWindow,
+			Now:         now,
+
+			JournalCommand:   opts.journalctl,
+			JournalDirectory: directory,
+			JournalMachine:   opts.machine,
+			JournalBoot:      opts.boot,
+
+			CurrentBootCutoff: opts.currentBootCutoff,
+
+			ShutdownInitiator: opts.reason,
+			JournalCache:      journalCache,
+			SkipSuspend:       opts.noSuspend,
+			SuspendWorkers:    opts.suspendWorkers,
+
+			Logf:  logf,
+			Input: input,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(directories) > 1 {
+			for i := range events {
+				events[i].Host = filepath.Base(directory)
 			}
 		}
 
-		fmt.Printf("\nLongest session: %s (%s)\n",
-			formatDuration(longest.Duration),
-			longest.Start.Format("2006-01-02 15:04"),
-		)
-		fmt.Printf("Shortest session: %s (%s)\n",
-			formatDuration(shortest.Duration),
-			shortest.Start.Format("2006-01-02 15:04"),
-		)
+		// Sessions are built from the whole history and clipped later, a
+		// history cut at --until would leave its last suspend asleep
+		sessions, err := uptime.CalculateSessionsAt(events, now())
+		if err != nil {
+			return nil, nil, err
+		}
+		events = uptime.ConvertEvents(uptime.FilterEvents(events, window), loc)
+		allEvents = append(allEvents, events...)
+		allSessions = append(allSessions, sessions...)
+	}
+
+	events, sessions := allEvents, allSessions
+	if len(directories) > 1 {
+		sort.SliceStable(events, func(i, j int) bool {
+			return events[i].Timestamp.Before(events[j].Timestamp)
+		})
+		sort.SliceStable(sessions, func(i, j int) bool {
+			return sessions[i].Start.Before(sessions[j].Start)
+		})
 	}
+
+	if opts.dbPath != "" {
+		sessions, err = syncSessionStore(opts.dbPath, sessions)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return events, sessions, nil
 }
 
-func formatDuration(d time.Duration) string {
-	hours := int(d.Hours())
-	minutes := int(d.Minutes()) % 60
-	seconds := int(d.Seconds()) % 60
+// journalDirectories expands a --directory glob like /srv/journals/* into
+// the directories it matches. Without --directory the system journal is read.
+func journalDirectories(pattern string) ([]string, error) {
+	if pattern == "" {
+		return []string{""}, nil
+	}
 
-	if hours > 0 {
-		return fmt.Sprintf("%dh %dm %ds", hours, minutes, seconds)
-	} else if minutes > 0 {
-		return fmt.Sprintf("%dm %ds", minutes, seconds)
-	} else {
-		return fmt.Sprintf("%ds", seconds)
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --directory pattern: %v", err)
+	}
+	if len(matches) == 0 {
+		// Not a glob, or nothing matched: let journalctl report the error
+		return []string{pattern}, nil
 	}
+	return matches, nil
+}
+
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
 }
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/keskad/loco/uptime"
+)
+
+func (settings renderSettings) newJSONEncoder(w io.Writer) *json.Encoder {
+	encoder := json.NewEncoder(w)
+	if settings.prettyJSON {
+		encoder.SetIndent("", "  ")
+	}
+	return encoder
+}
+
+type jsonSummary struct {
+	Count          int             `json:"count"`
+	TotalSeconds   int64           `json:"total_seconds"`
+	AverageSeconds int64           `json:"average_seconds"`
+	MedianSeconds  int64           `json:"median_seconds"`
+	StdDevSeconds  int64           `json:"stddev_seconds"`
+	Longest        *uptime.Session `json:"longest,omitempty"`
+	Shortest       *uptime.Session `json:"shortest,omitempty"`
+	Crashes        int             `json:"crashes"`
+	FailedSuspends int             `json:"failed_suspends"`
+	// FailedHibernations is included in FailedSuspends
+	FailedHibernations int `json:"failed_hibernations"`
+
+	SuspendedSeconds  int64 `json:"suspended_seconds"`
+	HibernatedSeconds int64 `json:"hibernated_seconds"`
+	Suspends          int   `json:"suspends"`
+	Hibernations      int   `json:"hibernations"`
+
+	ByType map[string]jsonTypeTotal `json:"by_type,omitempty"`
+}
+
+type jsonTypeTotal struct {
+	Count        int   `json:"count"`
+	TotalSeconds int64 `json:"total_seconds"`
+}
+
+type jsonReport struct {
+	Sessions []uptime.Session `json:"sessions"`
+	Summary  jsonSummary      `json:"summary"`
+}
+
+func writeJSON(w io.Writer, sessions []uptime.Session, summary uptime.Summary, settings renderSettings) error {
+	report := jsonReport{Sessions: []uptime.Session{}}
+	report.Sessions = append(report.Sessions, sessions...)
+
+	report.Summary = toJSONSummary(summary, settings)
+	return settings.newJSONEncoder(w).Encode(report)
+}
+
+func toJSONSummary(summary uptime.Summary, settings renderSettings) jsonSummary {
+	result := jsonSummary{
+		Count:          summary.Count,
+		TotalSeconds:   int64(summary.Total.Seconds()),
+		AverageSeconds: int64(summary.Average.Seconds()),
+		MedianSeconds:  int64(summary.Median.Seconds()),
+		StdDevSeconds:  int64(summary.StdDev.Seconds()),
+		Crashes:        summary.Crashes,
+		FailedSuspends: summary.FailedSuspends,
+
+		FailedHibernations: summary.FailedHibernations,
+
+		SuspendedSeconds:  int64(summary.Suspended.Seconds()),
+		HibernatedSeconds: int64(summary.Hibernated.Seconds()),
+		Suspends:          summary.Suspends,
+		Hibernations:      summary.Hibernations,
+	}
+	if summary.Count > 0 {
+		result.Longest = &summary.Longest
+		result.Shortest = &summary.Shortest
+	}
+	if settings.groupByType {
+		result.ByType = map[string]jsonTypeTotal{}
+		for _, total := range summary.ByType {
+			result.ByType[total.Type] = jsonTypeTotal{Count: total.Count, TotalSeconds: int64(total.Total.Seconds())}
+		}
+	}
+	return result
+}
+
+// writeJSONSummary writes the summary object of writeJSON on its own
+func writeJSONSummary(w io.Writer, summary uptime.Summary, settings renderSettings) error {
+	return settings.newJSONEncoder(w).Encode(toJSONSummary(summary, settings))
+}
+
+// writeSummaryFile writes the JSON summary to a sidecar file, next to
+// whatever the report prints
+func writeSummaryFile(path string, summary uptime.Summary, settings renderSettings) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("cannot create summary file: %v", err)
+	}
+	defer file.Close()
+
+	if err := writeJSONSummary(file, summary, settings); err != nil {
+		return fmt.Errorf("cannot write summary file: %v", err)
+	}
+	return file.Close()
+}
+
+type jsonRollup struct {
+	Period         string    `json:"period"`
+	Start          time.Time `json:"start"`
+	UptimeSeconds  int64     `json:"uptime_seconds"`
+	Sessions       int       `json:"sessions"`
+	Boots          int       `json:"boots"`
+	Crashes        int       `json:"crashes"`
+	AverageSeconds int64     `json:"average_seconds"`
+}
+
+// writeRollupJSON writes the --rollup periods as a JSON array
+func writeRollupJSON(w io.Writer, sessions []uptime.Session, period string, settings renderSettings) error {
+	result := []jsonRollup{}
+	for _, rollup := range uptime.Rollup(sessions, period) {
+		result = append(result, jsonRollup{
+			Period:         periodLabel(rollup.Start, period),
+			Start:          rollup.Start,
+			UptimeSeconds:  int64(rollup.Uptime.Seconds()),
+			Sessions:       rollup.Sessions,
+			Boots:          rollup.Boots,
+			Crashes:        rollup.Crashes,
+			AverageSeconds: int64(rollup.Average.Seconds()),
+		})
+	}
+	return settings.newJSONEncoder(w).Encode(result)
+}
+
+// writeEventsJSON writes the events sessions are calculated from as a JSON
+// array
+func writeEventsJSON(w io.Writer, events []uptime.Event, settings renderSettings) error {
+	return settings.newJSONEncoder(w).Encode(append([]uptime.Event{}, events...))
+}
+
+// readJSON reads the sessions of a report written by writeJSON. The summary
+// is ignored, it is calculated again from the sessions.
+func readJSON(r io.Reader) ([]uptime.Session, error) {
+	var report jsonReport
+	if err := json.NewDecoder(r).Decode(&report); err != nil {
+		return nil, fmt.Errorf("cannot parse JSON report: %v", err)
+	}
+
+	sessions := report.Sessions
+
+	// The report may have been written with --reverse
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Start.Before(sessions[j].Start)
+	})
+	return sessions, nil
+}
+
+// readJSONFile reads a JSON report from path, "-" means stdin
+func readJSONFile(path string) ([]uptime.Session, error) {
+	if path == "-" {
+		return readJSON(os.Stdin)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open JSON report: %v", err)
+	}
+	defer file.Close()
+	return readJSON(file)
+}
+
+// templateSession is what a --format template sees for each session
+type templateSession struct {
+	Start    time.Time
+	End      time.Time
+	Duration time.Duration
+	// DurationText is the duration as printed in the table
+	DurationText string
+	Type         string
+	BootID       string
+	Host         string
+}
+
+// writeTemplate executes the template once per session, each on its own line
+func writeTemplate(w io.Writer, tmpl *template.Template, sessions []uptime.Session, settings renderSettings) error {
+	for _, session := range sessions {
+		var line strings.Builder
+		err := tmpl.Execute(&line, templateSession{
+			Start:        session.Start,
+			End:          session.End,
+			Duration:     session.Duration,
+			DurationText: settings.formatDuration(session.Duration),
+			Type:         session.Type,
+			BootID:       session.BootID,
+			Host:         session.Host,
+		})
+		if err != nil {
+			return fmt.Errorf("cannot execute --format template: %v", err)
+		}
+
+		if _, err := fmt.Fprintln(w, strings.TrimSuffix(line.String(), "\n")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeNDJSON writes one JSON object per session and line, so consumers can
+// process the sessions one by one. It does not stream: the sessions are all
+// calculated before the first line is written.
+func writeNDJSON(w io.Writer, sessions []uptime.Session) error {
+	encoder := json.NewEncoder(w)
+	for _, session := range sessions {
+		if err := encoder.Encode(session); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeMachineOutput(w io.Writer, format string, sessions []uptime.Session, summary uptime.Summary, settings renderSettings) error {
+	switch format {
+	case "json":
+		return writeJSON(w, sessions, summary, settings)
+	case "ndjson":
+		return writeNDJSON(w, sessions)
+	case "csv":
+		return writeCSV(w, sessions)
+	case "prometheus":
+		return writePrometheus(w, sessions, summary)
+	case "markdown":
+		return writeMarkdown(w, sessions, summary, settings)
+	case "html":
+		return writeHTML(w, sessions, summary, settings)
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+func writeCSV(w io.Writer, sessions []uptime.Session) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"Start", "End", "Uptime", "Type"}); err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		record := []string{
+			session.Start.Format(time.RFC3339),
+			session.End.Format(time.RFC3339),
+			strconv.FormatInt(int64(session.Duration.Seconds()), 10),
+			session.Type,
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// writePrometheus writes metrics in the Prometheus text exposition format,
+// suitable for the node_exporter textfile collector.
+func writePrometheus(w io.Writer, sessions []uptime.Session, summary uptime.Summary) error {
+	current := time.Duration(0)
+	for _, session := range sessions {
+		if strings.HasSuffix(session.Type, "(still active)") {
+			current = session.Duration
+		}
+	}
+
+	metrics := []struct {
+		name       string
+		help       string
+		metricType string
+		value      float64
+	}{
+		// Both shrink as old sessions leave the journal or the window, which
+		// a counter must never do
+		{"uptime_sessions_total", "Number of work sessions.", "gauge", float64(summary.Count)},
+		{"uptime_seconds_total", "Total uptime of all sessions in seconds.", "gauge", summary.Total.Seconds()},
+		{"uptime_current_session_seconds", "Duration of the currently active session in seconds.", "gauge", current.Seconds()},
+		{"uptime_crashes_total", "Number of sessions that ended with a crash.", "counter", float64(summary.Crashes)},
+	}
+
+	for _, metric := range metrics {
+		_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %s\n",
+			metric.name, metric.help,
+			metric.name, metric.metricType,
+			metric.name, strconv.FormatFloat(metric.value, 'f', -1, 64),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeMarkdown renders the sessions as a GitHub-flavored Markdown table,
+// newest first, followed by the summary as a bulleted list.
+func writeMarkdown(w io.Writer, sessions []uptime.Session, summary uptime.Summary, settings renderSettings) error {
+	var b strings.Builder
+
+	b.WriteString("| Start | End | Uptime | Type |\n")
+	b.WriteString("|:------|:----|-------:|:-----|\n")
+	for i := len(sessions) - 1; i >= 0; i-- {
+		session := sessions[i]
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n",
+			session.Start.Format(settings.timeLayout),
+			session.End.Format(settings.timeLayout),
+			settings.formatDuration(session.Duration),
+			strings.ReplaceAll(session.Type, "|", "\\|"),
+		)
+	}
+
+	if summary.Count > 0 {
+		b.WriteString("\n**Summary**\n\n")
+		fmt.Fprintf(&b, "- Number of sessions: %d\n", summary.Count)
+		fmt.Fprintf(&b, "- Total uptime: %s\n", settings.formatDuration(summary.Total))
+		fmt.Fprintf(&b, "- Suspended time: %s (%s)\n", settings.formatDuration(summary.Suspended), plural(summary.Suspends, "suspend"))
+		fmt.Fprintf(&b, "- Hibernated time: %s (%s)\n", settings.formatDuration(summary.Hibernated), plural(summary.Hibernations, "hibernation"))
+		fmt.Fprintf(&b, "- Average session time: %s\n", settings.formatDuration(summary.Average))
+		fmt.Fprintf(&b, "- Median session time: %s\n", settings.formatDuration(summary.Median))
+		fmt.Fprintf(&b, "- Standard deviation: %s\n", settings.formatDuration(summary.StdDev))
+		fmt.Fprintf(&b, "- Crashes: %d\n", summary.Crashes)
+		if summary.FailedSuspends > 0 {
+			fmt.Fprintf(&b, "- Failed suspends: %d%s\n", summary.FailedSuspends, failedHibernations(summary))
+		}
+		fmt.Fprintf(&b, "- Longest session: %s (%s)\n",
+			settings.formatDuration(summary.Longest.Duration),
+			summary.Longest.Start.Format(settings.shortTimeLayout),
+		)
+		fmt.Fprintf(&b, "- Shortest session: %s (%s)\n",
+			settings.formatDuration(summary.Shortest.Duration),
+			summary.Shortest.Start.Format(settings.shortTimeLayout),
+		)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/keskad/loco/uptime"
+	_ "modernc.org/sqlite"
+)
+
+// SessionStore keeps sessions in a SQLite database, so the history survives
+// journal rotation.
+type SessionStore struct {
+	db *sql.DB
+}
+
+func openSessionStore(path string) (*SessionStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open database: %v", err)
+	}
+
+	store := &SessionStore{db: db}
+	if err := store.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	_, err = db.Exec(createSessionsTable)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("cannot create sessions table: %v", err)
+	}
+
+	return store, nil
+}
+
+// createSessionsTable keys sessions by host and start in UTC, so runs with
+// different --tz values store the same session once
+const createSessionsTable = `CREATE TABLE IF NOT EXISTS sessions (
+	host           TEXT NOT NULL DEFAULT '',
+	start          TEXT NOT NULL,
+	end            TEXT NOT NULL,
+	type           TEXT NOT NULL,
+	asleep_seconds INTEGER NOT NULL DEFAULT 0,
+	boot_id        TEXT NOT NULL DEFAULT '',
+	clock_adjusted INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (host, start)
+)`
+
+// migrate converts a table of older versions, keyed by the start in the
+// time zone of the run that stored it
+func (s *SessionStore) migrate() error {
+	var columns, hosts int
+	err := s.db.QueryRow(`SELECT COUNT(*), COALESCE(SUM(name = 'host'), 0) FROM pragma_table_info('sessions')`).Scan(&columns, &hosts)
+	if err != nil {
+		return fmt.Errorf("cannot read sessions table: %v", err)
+	}
+	if columns == 0 || hosts > 0 {
+		return nil
+	}
+
+	sessions, err := s.load(`SELECT '', start, end, type, asleep_seconds, '', 0 FROM sessions`)
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(`DROP TABLE sessions`); err != nil {
+		return fmt.Errorf("cannot migrate sessions table: %v", err)
+	}
+	if _, err := s.db.Exec(createSessionsTable); err != nil {
+		return fmt.Errorf("cannot migrate sessions table: %v", err)
+	}
+	return s.Save(sessions)
+}
+
+func (s *SessionStore) Close() error {
+	return s.db.Close()
+}
+
+// Save upserts sessions keyed by their host and start time
+func (s *SessionStore) Save(sessions []uptime.Session) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	statement, err := tx.Prepare(`INSERT INTO sessions (host, start, end, type, asleep_seconds, boot_id, clock_adjusted) VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(host, start) DO UPDATE SET end = excluded.end, type = excluded.type, asleep_seconds = excluded.asleep_seconds,
+			boot_id = excluded.boot_id, clock_adjusted = excluded.clock_adjusted`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer statement.Close()
+
+	for _, session := range sessions {
+		_, err := statement.Exec(
+			session.Host,
+			session.Start.UTC().Format(time.RFC3339Nano),
+			session.End.UTC().Format(time.RFC3339Nano),
+			session.Type,
+			int64(session.Asleep.Seconds()),
+			session.BootID,
+			session.ClockAdjusted,
+		)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("cannot store session: %v", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *SessionStore) Load() ([]uptime.Session, error) {
+	return s.load(`SELECT host, start, end, type, asleep_seconds, boot_id, clock_adjusted FROM sessions ORDER BY start`)
+}
+
+// load reads the sessions selected by query, which returns the columns of
+// the sessions table in their order
+func (s *SessionStore) load(query string) ([]uptime.Session, error) {
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read sessions: %v", err)
+	}
+	defer rows.Close()
+
+	sessions := []uptime.Session{}
+	for rows.Next() {
+		var host, start, end, sessionType, bootID string
+		var asleep int64
+		var clockAdjusted bool
+		if err := rows.Scan(&host, &start, &end, &sessionType, &asleep, &bootID, &clockAdjusted); err != nil {
+			return nil, err
+		}
+
+		startTime, err := time.Parse(time.RFC3339Nano, start)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start time %q in database: %v", start, err)
+		}
+		endTime, err := time.Parse(time.RFC3339Nano, end)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end time %q in database: %v", end, err)
+		}
+
+		sessions = append(sessions, uptime.Session{
+			Start:    startTime,
+			End:      endTime,
+			Duration: endTime.Sub(startTime),
+			Type:     sessionType,
+			Asleep:   time.Duration(asleep) * time.Second,
+			BootID:   bootID,
+			Host:     host,
+
+			ClockAdjusted: clockAdjusted,
+		})
+	}
+
+	return sessions, rows.Err()
+}
+
+// mergeSessions combines stored sessions with freshly parsed ones. Fresh
+// sessions win, stored ones overlapping any of the same host are dropped.
+func mergeSessions(stored, fresh []uptime.Session) []uptime.Session {
+	result := append([]uptime.Session{}, fresh...)
+
+	for _, old := range stored {
+		overlaps := false
+		for _, session := range fresh {
+			if old.Host != session.Host {
+				continue
+			}
+			if old.Start.Equal(session.Start) ||
+				(old.Start.Before(session.End) && old.End.After(session.Start)) {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			result = append(result, old)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Start.Before(result[j].Start)
+	})
+
+	return result
+}
+
+// syncSessionStore saves the fresh sessions and returns them merged with the
+// history kept in the database.
+func syncSessionStore(path string, sessions []uptime.Session) ([]uptime.Session, error) {
+	store, err := openSessionStore(path)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	if err := store.Save(sessions); err != nil {
+		return nil, err
+	}
+
+	stored, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeSessions(stored, sessions), nil
+}
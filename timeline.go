@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/keskad/loco/uptime"
+)
+
+const (
+	timelineActive    = '#'
+	timelineSuspended = '~'
+	timelineOff       = '.'
+)
+
+// timelineBar renders one day as width cells. Each cell shows whichever
+// state (active, suspended or off) covered most of it.
+func timelineBar(sessions []uptime.Session, day time.Time, width int) string {
+	next := day.AddDate(0, 0, 1)
+	cellLength := next.Sub(day) / time.Duration(width)
+
+	var bar strings.Builder
+	for cell := 0; cell < width; cell++ {
+		from := day.Add(time.Duration(cell) * cellLength)
+		to := from.Add(cellLength)
+
+		active := time.Duration(0)
+		asleep := time.Duration(0)
+		for _, session := range sessions {
+			active += uptime.Overlap(session.Start, session.End, from, to)
+			asleep += uptime.Overlap(session.End, session.End.Add(session.Asleep), from, to)
+		}
+
+		switch {
+		case active > 0 && active >= asleep && active*2 >= cellLength-active-asleep:
+			bar.WriteRune(timelineActive)
+		case asleep > 0 && asleep*2 >= cellLength-active-asleep:
+			bar.WriteRune(timelineSuspended)
+		default:
+			bar.WriteRune(timelineOff)
+		}
+	}
+
+	return bar.String()
+}
+
+func displayTimeline(w io.Writer, sessions []uptime.Session, window uptime.TimeWindow, width int) {
+	from, to := uptime.SessionsRange(sessions, window)
+
+	fmt.Fprintf(w, "Timeline (%c active, %c suspended, %c off):\n", timelineActive, timelineSuspended, timelineOff)
+	fmt.Fprintln(w)
+
+	// Hour markers above the bars
+	header := []rune(strings.Repeat(" ", width))
+	for hour := 0; hour < 24; hour += 6 {
+		label := fmt.Sprintf("%02d", hour)
+		position := hour * width / 24
+		if position+len(label) <= width {
+			copy(header[position:], []rune(label))
+		}
+	}
+	fmt.Fprintf(w, "%-10s  %s\n", "", string(header))
+
+	for _, day := range uptime.UptimeByDay(sessions, from, to) {
+		fmt.Fprintf(w, "%s |%s|\n", day.Day.Format("2006-01-02"), timelineBar(sessions, day.Day, width))
+	}
+}
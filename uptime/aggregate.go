@@ -0,0 +1,333 @@
+package uptime
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+type DayUptime struct {
+	Day    time.Time
+	Uptime time.Duration
+}
+
+func StartOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// UptimeByDay sums the uptime of every calendar day between from and to,
+// splitting sessions that cross midnight. Days without uptime are included.
+func UptimeByDay(sessions []Session, from, to time.Time) []DayUptime {
+	days := []DayUptime{}
+	if to.Before(from) {
+		return days
+	}
+
+	// An exclusive end at midnight does not reach into the next day
+	last := StartOfDay(to)
+	if last.Equal(to) && to.After(from) {
+		last = last.AddDate(0, 0, -1)
+	}
+
+	for day := StartOfDay(from); !day.After(last); day = day.AddDate(0, 0, 1) {
+		next := day.AddDate(0, 0, 1)
+		uptime := time.Duration(0)
+
+		for _, session := range sessions {
+			start := session.Start
+			if start.Before(day) {
+				start = day
+			}
+			end := session.End
+			if end.After(next) {
+				end = next
+			}
+			if end.After(start) {
+				uptime += end.Sub(start)
+			}
+		}
+
+		days = append(days, DayUptime{Day: day, Uptime: uptime})
+	}
+
+	return days
+}
+
+// SessionsRange returns the period covered by the report: the time window if
+// one was given, otherwise the span of the sessions themselves.
+func SessionsRange(sessions []Session, window TimeWindow) (time.Time, time.Time) {
+	from, to := window.Since, window.Until
+	if len(sessions) == 0 {
+		return from, to
+	}
+
+	if from.IsZero() {
+		from = sessions[0].Start
+		for _, session := range sessions {
+			if session.Start.Before(from) {
+				from = session.Start
+			}
+		}
+	}
+	if to.IsZero() {
+		to = sessions[0].End
+		for _, session := range sessions {
+			if session.End.After(to) {
+				to = session.End
+			}
+		}
+	}
+
+	return from, to
+}
+
+// Overlap returns how much of [start, end) falls into [from, to)
+func Overlap(start, end, from, to time.Time) time.Duration {
+	if start.Before(from) {
+		start = from
+	}
+	if end.After(to) {
+		end = to
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// UptimeInWindow splits the window between start and end into the time the
+// machine was active, asleep after one of the sessions, and off. Sessions
+// may lie partially or completely outside the window.
+func UptimeInWindow(sessions []Session, start, end time.Time) (active, suspended, downtime time.Duration) {
+	for _, session := range sessions {
+		active += Overlap(session.Start, session.End, start, end)
+		suspended += Overlap(session.End, session.End.Add(session.Asleep), start, end)
+	}
+
+	downtime = end.Sub(start) - active - suspended
+	if downtime < 0 {
+		downtime = 0
+	}
+	return active, suspended, downtime
+}
+
+type Availability struct {
+	From       time.Time
+	To         time.Time
+	Uptime     time.Duration
+	Suspended  time.Duration
+	Downtime   time.Duration
+	Percent    float64
+	GapStart   time.Time
+	GapEnd     time.Time
+	LongestGap time.Duration
+}
+
+// CalculateAvailability measures how much of the period between from and to
+// is covered by sessions. Anything outside a session, including time spent
+// suspended or hibernated, counts as downtime.
+func CalculateAvailability(sessions []Session, from, to time.Time) Availability {
+	availability := Availability{From: from, To: to}
+
+	sorted := make([]Session, len(sessions))
+	copy(sorted, sessions)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+
+	availability.Uptime, availability.Suspended, _ = UptimeInWindow(sorted, from, to)
+
+	// Walk the sessions, measuring the gaps in front of each of them
+	cursor := from
+	for _, session := range sorted {
+		availability.recordGap(cursor, session.Start)
+		if session.End.After(cursor) {
+			cursor = session.End
+		}
+	}
+	availability.recordGap(cursor, to)
+
+	period := to.Sub(from)
+	availability.Downtime = period - availability.Uptime
+	if availability.Downtime < 0 {
+		availability.Downtime = 0
+	}
+	if period > 0 {
+		availability.Percent = float64(availability.Uptime) / float64(period) * 100
+	}
+
+	return availability
+}
+
+func (a *Availability) recordGap(start, end time.Time) {
+	if gap := end.Sub(start); gap > a.LongestGap {
+		a.LongestGap = gap
+		a.GapStart = start
+		a.GapEnd = end
+	}
+}
+
+type WeekBoots struct {
+	Year  int
+	Week  int
+	Boots int
+}
+
+// BootsPerWeek counts boot events per ISO week, in chronological order. Only
+// events inside the window are counted.
+func BootsPerWeek(events []Event, window TimeWindow) []WeekBoots {
+	weeks := []WeekBoots{}
+	for _, event := range events {
+		if event.Type != "boot" {
+			continue
+		}
+		if !window.Since.IsZero() && event.Timestamp.Before(window.Since) {
+			continue
+		}
+		if !window.Until.IsZero() && !event.Timestamp.Before(window.Until) {
+			continue
+		}
+
+		year, week := event.Timestamp.ISOWeek()
+		if n := len(weeks); n > 0 && weeks[n-1].Year == year && weeks[n-1].Week == week {
+			weeks[n-1].Boots++
+			continue
+		}
+		weeks = append(weeks, WeekBoots{Year: year, Week: week, Boots: 1})
+	}
+	return weeks
+}
+
+// DowntimeType is the type of the rows inserted by InsertDowntime
+const DowntimeType = "shutdown → boot (off)"
+
+// InsertDowntime adds a row for every period the machine was powered off,
+// i.e. between the end of a session and the next boot. Time spent suspended
+// or hibernated is not downtime. Sessions must be sorted by time.
+func InsertDowntime(sessions []Session) []Session {
+	result := []Session{}
+	for i, session := range sessions {
+		if i > 0 && SessionStart(session) == "boot" {
+			previous := sessions[i-1]
+			if session.Start.After(previous.End) {
+				result = append(result, Session{
+					Start:    previous.End,
+					End:      session.Start,
+					Duration: session.Start.Sub(previous.End),
+					Type:     DowntimeType,
+				})
+			}
+		}
+		result = append(result, session)
+	}
+	return result
+}
+
+// DurationBucket counts sessions whose duration is in [From, To). A zero To
+// means the bucket is open-ended.
+type DurationBucket struct {
+	From  time.Duration
+	To    time.Duration
+	Count int
+}
+
+// Histogram sorts sessions into buckets separated by the given ascending
+// boundaries, e.g. 1h, 4h, 8h gives <1h, 1h-4h, 4h-8h and 8h+.
+func Histogram(sessions []Session, boundaries []time.Duration) []DurationBucket {
+	buckets := []DurationBucket{}
+	from := time.Duration(0)
+	for _, boundary := range boundaries {
+		buckets = append(buckets, DurationBucket{From: from, To: boundary})
+		from = boundary
+	}
+	buckets = append(buckets, DurationBucket{From: from})
+
+	for _, session := range sessions {
+		for i := range buckets {
+			if buckets[i].To == 0 || session.Duration < buckets[i].To {
+				buckets[i].Count++
+				break
+			}
+		}
+	}
+	return buckets
+}
+
+// ParseBuckets parses a comma-separated list of ascending durations
+func ParseBuckets(value string) ([]time.Duration, error) {
+	boundaries := []time.Duration{}
+	for _, part := range strings.Split(value, ",") {
+		boundary, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		if boundary <= 0 {
+			return nil, fmt.Errorf("bucket boundary must be positive, got %s", boundary)
+		}
+		if len(boundaries) > 0 && boundary <= boundaries[len(boundaries)-1] {
+			return nil, fmt.Errorf("bucket boundaries must be ascending, %s follows %s", boundary, boundaries[len(boundaries)-1])
+		}
+		boundaries = append(boundaries, boundary)
+	}
+	return boundaries, nil
+}
+
+// PeriodRollup aggregates the sessions that started in one week or month
+type PeriodRollup struct {
+	Start    time.Time
+	Uptime   time.Duration
+	Sessions int
+	Boots    int
+	Crashes  int
+	Average  time.Duration
+}
+
+// ValidatePeriod checks that the period is one Rollup knows
+func ValidatePeriod(period string) error {
+	switch period {
+	case "week", "month":
+		return nil
+	default:
+		return fmt.Errorf("unknown period %q, expected week or month", period)
+	}
+}
+
+// StartOfPeriod truncates t to the Monday of its ISO week or the first day
+// of its month
+func StartOfPeriod(t time.Time, period string) time.Time {
+	day := StartOfDay(t)
+	if period == "month" {
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// Rollup groups sessions by the week or month they started in. Periods
+// without sessions are left out.
+func Rollup(sessions []Session, period string) []PeriodRollup {
+	periods := []PeriodRollup{}
+	for _, session := range sessions {
+		start := StartOfPeriod(session.Start, period)
+		if n := len(periods); n == 0 || !periods[n-1].Start.Equal(start) {
+			periods = append(periods, PeriodRollup{Start: start})
+		}
+
+		current := &periods[len(periods)-1]
+		current.Uptime += session.Duration
+		current.Sessions++
+		if SessionStart(session) == "boot" {
+			current.Boots++
+		}
+		// Failed suspends are not crashes, like in the summary
+		if IsCrash(session) && !IsFailedSuspend(session) {
+			current.Crashes++
+		}
+	}
+
+	for i := range periods {
+		periods[i].Average = periods[i].Uptime / time.Duration(periods[i].Sessions)
+	}
+	return periods
+}
@@ -0,0 +1,35 @@
+package uptime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUptimeInWindow(t *testing.T) {
+	// Up from 8 to 12, asleep until 14, up again from 14 to 18
+	sessions := []Session{
+		{Start: at(8), End: at(12), Duration: 4 * time.Hour, Type: "boot → suspend", Asleep: 2 * time.Hour},
+		{Start: at(14), End: at(18), Duration: 4 * time.Hour, Type: "resume → shutdown"},
+	}
+
+	tests := []struct {
+		name                        string
+		start, end                  time.Time
+		active, suspended, downtime time.Duration
+	}{
+		{"inside", at(9), at(11), 2 * time.Hour, 0, 0},
+		{"partial", at(11), at(15), 2 * time.Hour, 2 * time.Hour, 0},
+		{"whole day", at(0), at(24), 8 * time.Hour, 2 * time.Hour, 14 * time.Hour},
+		{"outside", at(19), at(23), 0, 0, 4 * time.Hour},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			active, suspended, downtime := UptimeInWindow(sessions, test.start, test.end)
+			if active != test.active || suspended != test.suspended || downtime != test.downtime {
+				t.Errorf("expected %s active, %s suspended, %s off, got %s, %s, %s",
+					test.active, test.suspended, test.downtime, active, suspended, downtime)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package uptime
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// journalCache keeps what was read from the journal about boots that have
+// ended. Those never change, so only newer boots have to be queried again.
+type journalCache struct {
+	// Key identifies the journal and options the cache was built with
+	Key string `json:"key"`
+	// Shutdowns holds the shutdown or crash event of every ended boot
+	Shutdowns map[string]Event `json:"shutdowns"`
+	// Suspends holds the suspend, resume and clock events up to HighWater
+	Suspends  []Event   `json:"suspends"`
+	HighWater time.Time `json:"high_water"`
+}
+
+func (j *Journal) cacheKey() string {
+	// Bumped whenever the cached events gain new details
+	key := "4|" + j.Directory + "|" + j.Machine
+	if j.ShutdownInitiator {
+		key += "|initiator"
+	}
+	if j.SkipSuspend {
+		key += "|nosuspend"
+	}
+	return key
+}
+
+// loadCache reads the cache, or returns an empty one when there is none or
+// it no longer matches the journal. A boot that disappeared from the boot
+// list means the journal was rotated, so the cache starts over.
+func (j *Journal) loadCache(boots []journalBoot) *journalCache {
+	empty := &journalCache{Key: j.cacheKey(), Shutdowns: map[string]Event{}}
+	if j.CachePath == "" {
+		return empty
+	}
+
+	data, err := os.ReadFile(j.CachePath)
+	if err != nil {
+		return empty
+	}
+
+	cache := &journalCache{}
+	if err := json.Unmarshal(data, cache); err != nil || cache.Key != empty.Key || cache.Shutdowns == nil {
+		return empty
+	}
+
+	listed := map[string]bool{}
+	for _, boot := range boots {
+		listed[boot.ID] = true
+	}
+	for id := range cache.Shutdowns {
+		if !listed[id] {
+			return empty
+		}
+	}
+
+	return cache
+}
+
+// saveCache writes the cache, a failure only costs speed on the next run
+func (j *Journal) saveCache(cache *journalCache) {
+	if j.CachePath == "" {
+		return
+	}
+
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(j.CachePath), 0o755); err != nil {
+		return
+	}
+
+	// Replace the file at once, a concurrent run must not read half of it
+	temporary := j.CachePath + ".tmp"
+	if err := os.WriteFile(temporary, data, 0o644); err != nil {
+		return
+	}
+	os.Rename(temporary, j.CachePath)
+}
@@ -0,0 +1,8 @@
+//go:build !windows
+
+package uptime
+
+// NewEventLog returns nil, the event log only exists on Windows
+func NewEventLog(runner CommandRunner) EventSource {
+	return nil
+}
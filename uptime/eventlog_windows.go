@@ -0,0 +1,121 @@
+//go:build windows
+
+package uptime
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// EventLog reads boot, shutdown and sleep history from the Windows System
+// event log using wevtutil.
+type EventLog struct {
+	runner CommandRunner
+}
+
+func NewEventLog(runner CommandRunner) EventSource {
+	return &EventLog{runner: runner}
+}
+
+// eventLogQuery selects the records EventLog understands:
+//
+//	6005 EventLog service started (boot)
+//	6006 EventLog service stopped (shutdown)
+//	6008 the previous shutdown was unexpected (crash)
+//	42   Kernel-Power: entering sleep
+//	1    Power-Troubleshooter: woke up
+const eventLogQuery = "*[System[(EventID=6005 or EventID=6006 or EventID=6008 or EventID=42 or EventID=1)]]"
+
+type eventLogRecord struct {
+	System struct {
+		Provider struct {
+			Name string `xml:"Name,attr"`
+		} `xml:"Provider"`
+		EventID     int `xml:"EventID"`
+		TimeCreated struct {
+			SystemTime string `xml:"SystemTime,attr"`
+		} `xml:"TimeCreated"`
+	} `xml:"System"`
+}
+
+func (e *EventLog) Events() ([]Event, error) {
+	output, err := e.runner.Run("wevtutil", "qe", "System", "/q:"+eventLogQuery, "/f:xml")
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the System event log: %v", err)
+	}
+
+	events := []Event{}
+
+	// The records are concatenated <Event> elements without a root
+	decoder := xml.NewDecoder(bytes.NewReader(output))
+	for {
+		var record eventLogRecord
+		err := decoder.Decode(&record)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse the System event log: %v", err)
+		}
+
+		eventType := ""
+		switch {
+		case record.System.EventID == 6005:
+			eventType = "boot"
+		case record.System.EventID == 6006:
+			eventType = "shutdown"
+		case record.System.EventID == 6008:
+			eventType = "crash"
+		case record.System.EventID == 42 && record.System.Provider.Name == "Microsoft-Windows-Kernel-Power":
+			eventType = "suspend"
+		case record.System.EventID == 1 && record.System.Provider.Name == "Microsoft-Windows-Power-Troubleshooter":
+			eventType = "resume"
+		default:
+			continue
+		}
+
+		timestamp, err := time.Parse(time.RFC3339Nano, record.System.TimeCreated.SystemTime)
+		if err != nil {
+			continue
+		}
+
+		events = append(events, Event{
+			Timestamp: timestamp.Local(),
+			Type:      eventType,
+		})
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+
+	return moveCrashesBeforeBoot(events), nil
+}
+
+// moveCrashesBeforeBoot places every crash right before the boot it was
+// reported in. 6008 is written while the machine boots again and does not
+// say when the crash happened, but it has to end the previous session.
+func moveCrashesBeforeBoot(events []Event) []Event {
+	for i, event := range events {
+		if event.Type != "crash" {
+			continue
+		}
+
+		for j := i - 1; j >= 0; j-- {
+			if events[j].Type == "boot" {
+				events[i].Timestamp = events[j].Timestamp.Add(-time.Nanosecond)
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+	return events
+}
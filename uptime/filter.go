@@ -0,0 +1,279 @@
+package uptime
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeWindow limits the report to a period of time. A zero Since or Until
+// means the window is unbounded on that side.
+type TimeWindow struct {
+	Since time.Time
+	Until time.Time
+}
+
+// NewTimeWindow parses --since and --until style bounds into a window.
+func NewTimeWindow(since, until string, now time.Time, loc *time.Location) (TimeWindow, error) {
+	window := TimeWindow{}
+
+	var err error
+	if window.Since, err = ParseTimeBound(since, now, loc); err != nil {
+		return window, fmt.Errorf("invalid --since value: %v", err)
+	}
+	if window.Until, err = ParseTimeBound(until, now, loc); err != nil {
+		return window, fmt.Errorf("invalid --until value: %v", err)
+	}
+
+	if !window.Since.IsZero() && !window.Until.IsZero() && window.Since.After(window.Until) {
+		return window, fmt.Errorf("--since (%s) is after --until (%s)",
+			window.Since.Format("2006-01-02 15:04:05"),
+			window.Until.Format("2006-01-02 15:04:05"),
+		)
+	}
+
+	return window, nil
+}
+
+// ParseTimeBound accepts an absolute date (2025-01-02, optionally with a time)
+// or a relative expression like "7d", "24h" or "90m" counted back from now.
+// Absolute dates are interpreted in loc.
+func ParseTimeBound(value string, now time.Time, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	// Relative: days are not supported by time.ParseDuration
+	if strings.HasSuffix(value, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(value, "d")); err == nil && days >= 0 {
+			return now.Add(-time.Duration(days) * 24 * time.Hour), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+
+	// Absolute
+	layouts := []string{
+		time.RFC3339,
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04:05",
+		"2006-01-02 15:04",
+		"2006-01-02",
+	}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%q is neither a date (2006-01-02) nor a relative time (7d, 24h)", value)
+}
+
+// IsSet reports whether the window is bounded on any side.
+func (w TimeWindow) IsSet() bool {
+	return !w.Since.IsZero() || !w.Until.IsZero()
+}
+
+// ReachesNow reports whether the window extends to the present, so whether
+// the machine is up or asleep right now is part of it.
+func (w TimeWindow) ReachesNow(now time.Time) bool {
+	return w.Until.IsZero() || !w.Until.Before(now)
+}
+
+// FilterEvents drops events outside the window, but keeps the last event
+// before it and the first one after it, so sessions crossing a boundary are
+// still built and can be clipped afterwards. Clock steps do not start or end
+// a session, so they are not counted as that event.
+func FilterEvents(events []Event, window TimeWindow) []Event {
+	if !window.IsSet() {
+		return events
+	}
+
+	// The events from the last one preceding the window to the first one
+	// following it are kept
+	first, last := 0, len(events)-1
+	for i, event := range events {
+		if event.Type == "clock" {
+			continue
+		}
+		if !window.Since.IsZero() && event.Timestamp.Before(window.Since) {
+			first = i
+		}
+		if !window.Until.IsZero() && event.Timestamp.After(window.Until) {
+			last = i
+			break
+		}
+	}
+
+	result := []Event{}
+	if first <= last {
+		result = append(result, events[first:last+1]...)
+	}
+	return result
+}
+
+// ClipSessions trims sessions so they fit in the window and drops the ones
+// that are entirely outside of it.
+func ClipSessions(sessions []Session, window TimeWindow) []Session {
+	if !window.IsSet() {
+		return sessions
+	}
+
+	result := []Session{}
+	for _, session := range sessions {
+		clipped := false
+		if !window.Since.IsZero() {
+			if !session.End.After(window.Since) {
+				continue
+			}
+			if session.Start.Before(window.Since) {
+				session.Start = window.Since
+				clipped = true
+			}
+		}
+		if !window.Until.IsZero() {
+			if !session.Start.Before(window.Until) {
+				continue
+			}
+			if session.End.After(window.Until) {
+				session.End = window.Until
+				clipped = true
+			}
+			if session.End.Add(session.Asleep).After(window.Until) {
+				session.Asleep = window.Until.Sub(session.End)
+			}
+		}
+		// The duration of a session merged across sleeps is shorter than
+		// its span, it is kept unless the span changed
+		if clipped {
+			session.Duration = session.End.Sub(session.Start)
+		}
+		result = append(result, session)
+	}
+
+	return result
+}
+
+// EndAtWindow drops the current state from sessions clipped to a window
+// that ended before now. A session still active is cut at the end of the
+// window, "boot → (still active)" becomes "boot → (after --until)", and a
+// session followed by a sleep lasting until now is no longer "(asleep)".
+func EndAtWindow(sessions []Session) []Session {
+	result := make([]Session, len(sessions))
+	for i, session := range sessions {
+		session.Type = strings.TrimSuffix(session.Type, " (asleep)")
+		if start, found := strings.CutSuffix(session.Type, "(still active)"); found {
+			session.Type = start + "(after --until)"
+		}
+		result[i] = session
+	}
+	return result
+}
+
+// LimitSessions keeps the n most recent sessions. Zero means no limit.
+func LimitSessions(sessions []Session, n int) []Session {
+	if n <= 0 || n >= len(sessions) {
+		return sessions
+	}
+	return sessions[len(sessions)-n:]
+}
+
+// FilterSessionsByDuration drops sessions shorter than min. Zero keeps all.
+func FilterSessionsByDuration(sessions []Session, min time.Duration) []Session {
+	if min <= 0 {
+		return sessions
+	}
+
+	result := []Session{}
+	for _, session := range sessions {
+		if session.Duration >= min {
+			result = append(result, session)
+		}
+	}
+	return result
+}
+
+// ReverseSessions returns the sessions newest first
+func ReverseSessions(sessions []Session) []Session {
+	result := make([]Session, len(sessions))
+	for i, session := range sessions {
+		result[len(sessions)-1-i] = session
+	}
+	return result
+}
+
+// LongestSessions returns the n longest sessions, longest first
+func LongestSessions(sessions []Session, n int) []Session {
+	result := append([]Session{}, sessions...)
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Duration > result[j].Duration
+	})
+	if n < len(result) {
+		result = result[:n]
+	}
+	return result
+}
+
+// Hosts lists the hosts of the sessions in alphabetical order. Sessions of
+// a single, unnamed machine give no hosts.
+func Hosts(sessions []Session) []string {
+	hosts := []string{}
+	for _, session := range sessions {
+		if session.Host != "" && !slices.Contains(hosts, session.Host) {
+			hosts = append(hosts, session.Host)
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// FilterSessionsByHost keeps the sessions of one host
+func FilterSessionsByHost(sessions []Session, host string) []Session {
+	result := []Session{}
+	for _, session := range sessions {
+		if session.Host == host {
+			result = append(result, session)
+		}
+	}
+	return result
+}
+
+// EventTypes lists the event types sessions can start or end with
+var EventTypes = []string{"boot", "shutdown", "crash", "suspend", "hibernate", "resume"}
+
+// ParseEventTypes parses a comma-separated list of event types.
+func ParseEventTypes(value string) ([]string, error) {
+	types := []string{}
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !slices.Contains(EventTypes, name) {
+			return nil, fmt.Errorf("unknown event type %q, expected one of %s", name, strings.Join(EventTypes, ", "))
+		}
+		types = append(types, name)
+	}
+	return types, nil
+}
+
+// FilterSessionsByType keeps sessions that start or end with one of the
+// given event types. An empty list keeps everything.
+func FilterSessionsByType(sessions []Session, types []string) []Session {
+	if len(types) == 0 {
+		return sessions
+	}
+
+	result := []Session{}
+	for _, session := range sessions {
+		if slices.Contains(types, SessionStart(session)) || slices.Contains(types, SessionEnd(session)) {
+			result = append(result, session)
+		}
+	}
+	return result
+}
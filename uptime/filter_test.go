@@ -0,0 +1,66 @@
+package uptime
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFilterEvents(t *testing.T) {
+	events := []Event{
+		{Timestamp: at(8), Type: "boot"},
+		{Timestamp: at(12), Type: "suspend"},
+		{Timestamp: at(13), Type: "clock"},
+		{Timestamp: at(14), Type: "resume"},
+		{Timestamp: at(15), Type: "clock"},
+		{Timestamp: at(18), Type: "shutdown"},
+		{Timestamp: at(20), Type: "boot"},
+	}
+
+	tests := []struct {
+		name     string
+		window   TimeWindow
+		expected []string
+	}{
+		{"no window", TimeWindow{}, []string{"boot", "suspend", "clock", "resume", "clock", "shutdown", "boot"}},
+		{"since keeps the event before a clock step", TimeWindow{Since: at(14)}, []string{"suspend", "clock", "resume", "clock", "shutdown", "boot"}},
+		{"until keeps the event after a clock step", TimeWindow{Until: at(14).Add(30 * time.Minute)}, []string{"boot", "suspend", "clock", "resume", "clock", "shutdown"}},
+		{"both", TimeWindow{Since: at(13).Add(30 * time.Minute), Until: at(19)}, []string{"suspend", "clock", "resume", "clock", "shutdown", "boot"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			types := []string{}
+			for _, event := range FilterEvents(events, test.window) {
+				types = append(types, event.Type)
+			}
+			if actual, expected := strings.Join(types, " "), strings.Join(test.expected, " "); actual != expected {
+				t.Errorf("expected %s, got %s", expected, actual)
+			}
+		})
+	}
+}
+
+func TestEndAtWindow(t *testing.T) {
+	sessions := []Session{
+		{Start: at(8), End: at(12), Type: "boot → suspend (asleep)"},
+		{Start: at(14), End: at(16), Type: "boot → (still active)"},
+		{Start: at(17), End: at(18), Type: "boot → shutdown"},
+	}
+
+	types := []string{}
+	for _, session := range EndAtWindow(sessions) {
+		types = append(types, session.Type)
+	}
+	expected := "boot → suspend, boot → (after --until), boot → shutdown"
+	if actual := strings.Join(types, ", "); actual != expected {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+
+	if (TimeWindow{Until: at(16)}).ReachesNow(at(17)) {
+		t.Error("expected a window ending before now not to reach it")
+	}
+	if !(TimeWindow{Since: at(16)}).ReachesNow(at(17)) {
+		t.Error("expected a window without an end to reach now")
+	}
+}
@@ -0,0 +1,165 @@
+package uptime
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// BusinessHours is a daily time window on some days of the week
+type BusinessHours struct {
+	// From and To are the time of day the window opens and closes
+	From time.Duration
+	To   time.Duration
+	// Days are the weekdays the window applies to
+	Days [7]bool
+}
+
+// ParseBusinessHours parses a window like "09:00-18:00", optionally
+// preceded by weekdays: "Mon-Fri 09:00-18:00" or "Mon,Wed,Fri 08:00-12:00".
+// Without weekdays the window applies to every day.
+func ParseBusinessHours(value string) (BusinessHours, error) {
+	hours := BusinessHours{}
+
+	fields := strings.Fields(value)
+	if len(fields) == 0 || len(fields) > 2 {
+		return hours, fmt.Errorf("expected hours like 09:00-18:00 or Mon-Fri 09:00-18:00, got %q", value)
+	}
+
+	if len(fields) == 2 {
+		days, err := parseWeekdays(fields[0])
+		if err != nil {
+			return hours, err
+		}
+		hours.Days = days
+	} else {
+		hours.Days = [7]bool{true, true, true, true, true, true, true}
+	}
+
+	from, to, ok := strings.Cut(fields[len(fields)-1], "-")
+	if !ok {
+		return hours, fmt.Errorf("expected hours like 09:00-18:00, got %q", fields[len(fields)-1])
+	}
+	var err error
+	if hours.From, err = parseClock(from); err != nil {
+		return hours, err
+	}
+	if hours.To, err = parseClock(to); err != nil {
+		return hours, err
+	}
+	if hours.To <= hours.From {
+		return hours, fmt.Errorf("hours must end after they start, got %s", fields[len(fields)-1])
+	}
+
+	return hours, nil
+}
+
+// parseClock parses a time of day like "09:00", "24:00" is the end of the day
+func parseClock(value string) (time.Duration, error) {
+	if value == "24:00" {
+		return 24 * time.Hour, nil
+	}
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, expected HH:MM", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// parseWeekdays parses "Mon-Fri" or "Mon,Wed,Fri", ranges may wrap around
+// the weekend like "Sat-Sun"
+func parseWeekdays(value string) ([7]bool, error) {
+	days := [7]bool{}
+	for _, part := range strings.Split(value, ",") {
+		first, last, isRange := strings.Cut(part, "-")
+		from, err := parseWeekday(first)
+		if err != nil {
+			return days, err
+		}
+		to := from
+		if isRange {
+			if to, err = parseWeekday(last); err != nil {
+				return days, err
+			}
+		}
+
+		for day := from; ; day = (day + 1) % 7 {
+			days[day] = true
+			if day == to {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// parseWeekday parses a weekday name, abbreviated to at least three letters
+func parseWeekday(value string) (time.Weekday, error) {
+	name := strings.ToLower(value)
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if len(name) >= 3 && strings.HasPrefix(strings.ToLower(day.String()), name) {
+			return day, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown weekday %q, expected Mon, Tue, ...", value)
+}
+
+// ClipToBusinessHours keeps the parts of the sessions that fall into the
+// business hours, in the time zone of each session. A session spanning
+// several days stays one session from its first to its last minute within
+// business hours, its duration counts only the time within them.
+func ClipToBusinessHours(sessions []Session, hours BusinessHours) []Session {
+	result := []Session{}
+	for _, session := range sessions {
+		clipped := session
+		clipped.Duration = 0
+		for day := StartOfDay(session.Start); day.Before(session.End); day = day.AddDate(0, 0, 1) {
+			if !hours.Days[day.Weekday()] {
+				continue
+			}
+
+			open, closed := clockOn(day, hours.From), clockOn(day, hours.To)
+			start, end := maxTime(session.Start, open), minTime(session.End, closed)
+			if !end.After(start) {
+				continue
+			}
+
+			if clipped.Duration == 0 {
+				clipped.Start = start
+			}
+			clipped.End = end
+			clipped.Duration += end.Sub(start)
+		}
+		if clipped.Duration == 0 {
+			continue
+		}
+
+		// The sleep follows the end of the whole session only
+		if !clipped.End.Equal(session.End) {
+			clipped.Asleep = 0
+		}
+		result = append(result, clipped)
+	}
+	return result
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// clockOn returns the wall clock time of day on the given day, which stays
+// right on days with a DST change
+func clockOn(day time.Time, clock time.Duration) time.Time {
+	year, month, date := day.Date()
+	return time.Date(year, month, date, int(clock/time.Hour), int(clock%time.Hour/time.Minute), 0, 0, day.Location())
+}
@@ -0,0 +1,54 @@
+package uptime
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClipToBusinessHours(t *testing.T) {
+	hours, err := ParseBusinessHours("Mon-Fri 09:00-17:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// at(0) is a Tuesday
+	tests := []struct {
+		name     string
+		session  Session
+		expected string
+		duration time.Duration
+		asleep   time.Duration
+	}{
+		{"within hours", Session{Start: at(10), End: at(12), Asleep: time.Hour}, "2025-10-28T10:00:00Z - 2025-10-28T12:00:00Z", 2 * time.Hour, time.Hour},
+		{"into the evening", Session{Start: at(15), End: at(20), Asleep: time.Hour}, "2025-10-28T15:00:00Z - 2025-10-28T17:00:00Z", 2 * time.Hour, 0},
+		{"over several days", Session{Start: at(8), End: at(24 + 24 + 12)}, "2025-10-28T09:00:00Z - 2025-10-30T12:00:00Z", 8*time.Hour + 8*time.Hour + 3*time.Hour, 0},
+		{"weekend", Session{Start: at(4*24 + 8), End: at(4*24 + 18)}, "", 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sessions := ClipToBusinessHours([]Session{test.session}, hours)
+			if test.expected == "" {
+				if len(sessions) != 0 {
+					t.Fatalf("expected no sessions, got:\n%s", formatSessions(sessions))
+				}
+				return
+			}
+			if len(sessions) != 1 {
+				t.Fatalf("expected one session, got:\n%s", formatSessions(sessions))
+			}
+
+			session := sessions[0]
+			if actual := strings.TrimSpace(formatSessions(sessions)); actual != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, actual)
+			}
+			if session.Duration != test.duration {
+				t.Errorf("expected a duration of %s, got %s", test.duration, session.Duration)
+			}
+			if session.Asleep != test.asleep {
+				t.Errorf("expected %s asleep, got %s", test.asleep, session.Asleep)
+			}
+		})
+	}
+}
@@ -0,0 +1,683 @@
+package uptime
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Journal reads events from the systemd journal using journalctl.
+type Journal struct {
+	runner CommandRunner
+
+	// Command is the journalctl executable, looked up in PATH when it is
+	// not a path. Empty means "journalctl".
+	Command string
+
+	// Directory reads an exported journal instead of the system one (-D)
+	Directory string
+	// Machine reads the journal of a local container (-M)
+	Machine string
+	// Boot limits the events to one boot, given as an offset (-b) or ID
+	Boot string
+	// ShutdownInitiator looks up who requested each shutdown
+	ShutdownInitiator bool
+	// Now returns the current time, time.Now when nil
+	Now func() time.Time
+	// SkipSuspend leaves out suspend and hibernate events, saving the
+	// queries on machines that never sleep
+	SkipSuspend bool
+	// SuspendWorkers queries suspend and hibernate events per boot with up
+	// to this many concurrent journalctl calls. Zero reads the whole journal
+	// at once.
+	SuspendWorkers int
+	// CachePath keeps what is known about ended boots in this file, so
+	// that repeated runs only query the journal for newer boots
+	CachePath string
+	// Logf reports progress, nil discards it
+	Logf func(format string, args ...any)
+	// CurrentBootCutoff is how recent the last entry of an exported
+	// journal's last boot must be for the boot to count as still running,
+	// a minute when zero
+	CurrentBootCutoff time.Duration
+}
+
+func NewJournal(runner CommandRunner) *Journal {
+	return &Journal{runner: runner}
+}
+
+func (j *Journal) now() time.Time {
+	if j.Now != nil {
+		return j.Now()
+	}
+	return time.Now()
+}
+
+func (j *Journal) logf(format string, args ...any) {
+	if j.Logf != nil {
+		j.Logf(format, args...)
+	}
+}
+
+// journalctl runs journalctl against the configured journal
+func (j *Journal) journalctl(args ...string) ([]byte, error) {
+	common := []string{}
+	if j.Directory != "" {
+		common = append(common, "--directory="+j.Directory)
+	}
+	if j.Machine != "" {
+		common = append(common, "--machine="+j.Machine)
+	}
+	args = append(common, args...)
+
+	command := j.Command
+	if command == "" {
+		command = "journalctl"
+	}
+
+	started := time.Now()
+	output, err := j.runner.Run(command, args...)
+	j.logf("journalctl %s took %s", strings.Join(args, " "), time.Since(started).Round(time.Millisecond))
+	return output, err
+}
+
+// journalBoot is a single entry of journalctl --list-boots
+type journalBoot struct {
+	Index     int
+	ID        string
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+func (j *Journal) Events() ([]Event, error) {
+	// First, get the list of all boots with timestamps
+	j.logf("querying boot list...")
+	boots, err := j.listBoots()
+	if err != nil {
+		return nil, err
+	}
+	j.logf("parsed %d boots", len(boots))
+
+	// The cache covers the whole boot list, also when only one boot is read
+	cache := j.loadCache(boots)
+	if j.CachePath != "" {
+		j.logf("%d boots and %d suspend events cached", len(cache.Shutdowns), len(cache.Suspends))
+	}
+
+	suspendBoot := ""
+	if j.Boot != "" {
+		boot, err := selectBoot(boots, j.Boot)
+		if err != nil {
+			return nil, err
+		}
+		boots = []journalBoot{boot}
+		suspendBoot = boot.ID
+	}
+
+	// Boots that ended before this one cannot change anymore
+	highWater := time.Time{}
+
+	events := []Event{}
+	for _, boot := range boots {
+		// Add boot event
+		events = append(events, Event{
+			Timestamp: boot.StartTime,
+			Type:      "boot",
+			BootID:    boot.ID,
+		})
+
+		// Add shutdown event (if boot has ended)
+		if !j.isCurrentBoot(boot) {
+			shutdown, cached := cache.Shutdowns[boot.ID]
+			if !cached {
+				shutdown = j.shutdownEvent(boot)
+				cache.Shutdowns[boot.ID] = shutdown
+			}
+			events = append(events, shutdown)
+
+			if boot.EndTime.After(highWater) {
+				highWater = boot.EndTime
+			}
+		}
+	}
+
+	// Now try to detect suspend/resume and clock steps for all boots
+	if !j.SkipSuspend {
+		j.logf("querying suspend events...")
+	}
+	if suspendBoot != "" {
+		if !j.SkipSuspend {
+			events = append(events, j.detectSuspendResume(suspendBoot, time.Time{})...)
+		}
+		events = append(events, j.clockChanges(suspendBoot, time.Time{})...)
+	} else {
+		// Only the part of the journal after the cached events is read
+		detected := j.clockChanges("", cache.HighWater)
+		switch {
+		case j.SkipSuspend:
+			// Machines that never sleep have nothing to look for
+		case j.SuspendWorkers > 0:
+			// Boots that ended before the high-water mark are cached
+			pending := []journalBoot{}
+			for _, boot := range boots {
+				if boot.EndTime.After(cache.HighWater) {
+					pending = append(pending, boot)
+				}
+			}
+			detected = append(detected, j.detectSuspendResumePerBoot(pending, j.SuspendWorkers)...)
+		default:
+			detected = append(detected, j.detectSuspendResume("", cache.HighWater)...)
+		}
+
+		suspends := cache.Suspends
+		for _, event := range detected {
+			if event.Timestamp.After(cache.HighWater) {
+				suspends = append(suspends, event)
+			}
+		}
+		events = append(events, suspends...)
+
+		if highWater.After(cache.HighWater) {
+			cache.Suspends = []Event{}
+			for _, event := range suspends {
+				if !event.Timestamp.After(highWater) {
+					cache.Suspends = append(cache.Suspends, event)
+				}
+			}
+			cache.HighWater = highWater
+		}
+	}
+
+	j.saveCache(cache)
+
+	// Sort chronologically
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+
+	return events, nil
+}
+
+// isCurrentBoot reports whether the boot is still running. journalctl
+// numbers the current boot 0, but the last boot of an exported journal
+// usually ended long ago, there the time of its last entry decides.
+func (j *Journal) isCurrentBoot(boot journalBoot) bool {
+	if j.Directory == "" {
+		return boot.Index == 0
+	}
+
+	cutoff := j.CurrentBootCutoff
+	if cutoff <= 0 {
+		cutoff = time.Minute
+	}
+	return !boot.EndTime.Before(j.now().Add(-cutoff))
+}
+
+// listBoots reads the boot list as JSON, which does not depend on the
+// column layout of the systemd version. Versions that cannot print it as
+// JSON fall back to parsing the text table.
+func (j *Journal) listBoots() ([]journalBoot, error) {
+	output, err := j.journalctl("--list-boots", "--no-pager", "--output=json")
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, fmt.Errorf("journalctl not found; is this a systemd system? use --source=wtmp")
+	}
+	if err == nil {
+		if boots, err := parseBootsJSON(output); err == nil {
+			return boots, nil
+		}
+	}
+
+	output, err = j.journalctl("--list-boots", "--no-pager", "--output=short-iso")
+	if err != nil {
+		return nil, fmt.Errorf("cannot read boot list: %v", err)
+	}
+	return parseBootsText(output, j.now()), nil
+}
+
+// parseBootsJSON parses journalctl --list-boots --output=json, where the
+// first and last entries are microseconds since the epoch
+func parseBootsJSON(output []byte) ([]journalBoot, error) {
+	var entries []struct {
+		Index      int    `json:"index"`
+		BootID     string `json:"boot_id"`
+		FirstEntry int64  `json:"first_entry"`
+		LastEntry  int64  `json:"last_entry"`
+	}
+	if err := json.Unmarshal(output, &entries); err != nil {
+		return nil, err
+	}
+
+	boots := []journalBoot{}
+	for _, entry := range entries {
+		if entry.BootID == "" || entry.FirstEntry == 0 {
+			continue
+		}
+		boots = append(boots, journalBoot{
+			Index:     entry.Index,
+			ID:        entry.BootID,
+			StartTime: time.UnixMicro(entry.FirstEntry),
+			EndTime:   time.UnixMicro(entry.LastEntry),
+		})
+	}
+	return boots, nil
+}
+
+// parseBootsText parses the table printed by journalctl --list-boots. A
+// current boot without a complete last entry ends now.
+func parseBootsText(output []byte, now time.Time) []journalBoot {
+	boots := []journalBoot{}
+
+	// Parse each boot from --list-boots
+	bootScanner := bufio.NewScanner(strings.NewReader(string(output)))
+	// Some systemd versions print no header, or a blank line before it
+	headerChecked := false
+
+	// Find separator between dates (usually "—" or several spaces)
+	// We're looking for pattern: date + time + timezone, then next date.
+	// The weekday in front of each date is skipped, because it is
+	// localized ("Di", "mar.") and redundant anyway.
+	// The zone is an abbreviation like "CET" or a numeric offset.
+	dateRegex := regexp.MustCompile(`(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2} [+-]?[\w:]+)`)
+
+	for bootScanner.Scan() {
+		line := bootScanner.Text()
+		// Format: IDX BOOT_ID FIRST_ENTRY LAST_ENTRY
+		// Example: -10 3460c36536374bb48bb910bae80c34b6 Tue 2025-10-28 16:28:42 CET Thu 2025-10-30 00:14:40 CET
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if !headerChecked {
+			headerChecked = true
+			if strings.Contains(line, "BOOT ID") || strings.Contains(line, "IDX") {
+				continue
+			}
+		}
+
+		parts := strings.Fields(line)
+		if len(parts) < 3 {
+			continue
+		}
+
+		index, err := strconv.Atoi(parts[0])
+		if err != nil {
+			continue
+		}
+
+		// The last entry of the current boot keeps advancing and may be
+		// missing or incomplete, the boot lasts until now
+		dates := dateRegex.FindAllString(line, -1)
+		if len(dates) == 1 && index == 0 {
+			dates = append(dates, "")
+		}
+		if len(dates) < 2 {
+			continue
+		}
+
+		// Parse start time
+		startTime, err := parseBootTime(dates[0])
+		if err != nil {
+			continue
+		}
+
+		// Parse end time
+		endTime := now
+		if dates[1] != "" {
+			if endTime, err = parseBootTime(dates[1]); err != nil {
+				continue
+			}
+		}
+
+		boots = append(boots, journalBoot{
+			Index:     index,
+			ID:        parts[1],
+			StartTime: startTime,
+			EndTime:   endTime,
+		})
+	}
+
+	return boots
+}
+
+// bootTimeLayouts are the zone formats seen in --list-boots output
+var bootTimeLayouts = []string{
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 -07:00",
+}
+
+// zoneOffsets are the offsets of common zone abbreviations, used when the
+// abbreviation does not belong to the local time zone. time.Parse would
+// silently treat those as UTC.
+var zoneOffsets = map[string]int{
+	"WET": 0, "WEST": 1, "BST": 1, "CET": 1, "CEST": 2, "EET": 2, "EEST": 3, "MSK": 3,
+	"EST": -5, "EDT": -4, "CST": -6, "CDT": -5, "MST": -7, "MDT": -6, "PST": -8, "PDT": -7,
+	"JST": 9, "KST": 9, "AEST": 10, "AEDT": 11, "NZST": 12, "NZDT": 13,
+}
+
+// parseBootTime parses a --list-boots timestamp with whichever zone format
+// it uses. Abbreviations carry their real offset, so durations across a DST
+// change come out right, e.g. 02:30 CEST to 02:15 CET is 45 minutes.
+func parseBootTime(value string) (time.Time, error) {
+	var err error
+	for _, layout := range bootTimeLayouts {
+		var parsed time.Time
+		// journalctl prints local time, whose zone knows both the summer
+		// and the winter abbreviation
+		if parsed, err = time.ParseInLocation(layout, value, time.Local); err != nil {
+			continue
+		}
+
+		name, offset := parsed.Zone()
+		if known, ok := zoneOffsets[name]; ok && offset == 0 && known != 0 {
+			parsed = time.Date(parsed.Year(), parsed.Month(), parsed.Day(),
+				parsed.Hour(), parsed.Minute(), parsed.Second(), parsed.Nanosecond(),
+				time.FixedZone(name, known*60*60))
+		}
+		return parsed, nil
+	}
+	return time.Time{}, err
+}
+
+// selectBoot finds a boot by its offset, e.g. "-1", or by its ID
+func selectBoot(boots []journalBoot, boot string) (journalBoot, error) {
+	index, err := strconv.Atoi(boot)
+	for _, candidate := range boots {
+		if (err == nil && candidate.Index == index) || candidate.ID == boot {
+			return candidate, nil
+		}
+	}
+	return journalBoot{}, fmt.Errorf("boot %s not found in the boot list", boot)
+}
+
+// shutdownEvent tells how the boot ended
+func (j *Journal) shutdownEvent(boot journalBoot) Event {
+	endType := "shutdown"
+	clean, reason := j.shutdownReason(boot.ID)
+	if !clean {
+		endType = "crash"
+	}
+
+	if clean && j.ShutdownInitiator {
+		if j.batteryShutdown(boot) {
+			reason = joinReasons(reason, "battery")
+		}
+		if initiator := j.shutdownInitiator(boot.ID); initiator != "" {
+			reason = joinReasons(reason, initiator)
+		}
+	}
+
+	return Event{
+		Timestamp: boot.EndTime,
+		Type:      endType,
+		BootID:    boot.ID,
+		Reason:    reason,
+	}
+}
+
+// unattendedRebootMarker is what unattended-upgrade logs right before it
+// reboots for an upgrade that requires it, with Automatic-Reboot enabled
+const unattendedRebootMarker = "found /var/run/reboot-required, rebooting"
+
+// shutdownReason reports whether the boot reached shutdown.target and, as a
+// best-effort guess, why: "reboot" for a reboot.target, "planned reboot" when
+// unattended-upgrades asked for it, empty for a plain power off. If the
+// journal cannot be queried the boot is assumed to have ended cleanly.
+func (j *Journal) shutdownReason(bootID string) (bool, string) {
+	// unattended-upgrade runs in apt-daily-upgrade.service, the
+	// unattended-upgrades.service unit only waits for it at shutdown
+	output, err := j.journalctl("-b", bootID, "--no-pager", "-o", "short-iso",
+		"-u", "shutdown.target", "-u", "reboot.target", "-u", "apt-daily-upgrade.service")
+	if err != nil {
+		return true, ""
+	}
+
+	text := strings.ToLower(string(output))
+	if !strings.Contains(text, "reached target") {
+		return false, ""
+	}
+
+	rebooted := strings.Contains(text, "reached target reboot")
+	switch {
+	case rebooted && strings.Contains(text, unattendedRebootMarker):
+		return true, "planned reboot"
+	case rebooted:
+		return true, "reboot"
+	default:
+		return true, ""
+	}
+}
+
+// batteryMarkers are what UPower and logind log when the battery runs out
+var batteryMarkers = []string{
+	"battery level is critical",
+	"critical power level",
+	"critical action",
+	"battery is critically low",
+}
+
+// batteryWindow is how long before the end of a boot a critical battery
+// warning counts as the reason of the shutdown
+const batteryWindow = 5 * time.Minute
+
+// batteryShutdown reports whether the boot ended because the battery was
+// critically low. Machines without UPower simply never match.
+func (j *Journal) batteryShutdown(boot journalBoot) bool {
+	output, err := j.journalctl("-b", boot.ID,
+		fmt.Sprintf("--since=@%d", boot.EndTime.Add(-batteryWindow).Unix()),
+		"--no-pager", "-o", "short-iso", "-t", "upowerd", "-t", "systemd-logind")
+	if err != nil {
+		return false
+	}
+
+	text := strings.ToLower(string(output))
+	for _, marker := range batteryMarkers {
+		if strings.Contains(text, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// timeChangeMessageID marks the "Time has been changed" message systemd logs
+// when the clock is set, e.g. by systemd-timesyncd stepping it
+const timeChangeMessageID = "c7a787079b354eaaa9e77b371893cd27"
+
+// clockChanges reads when the system clock was stepped, of one boot or of
+// the whole journal, optionally only after since. Session durations across
+// such a step are unreliable.
+func (j *Journal) clockChanges(bootID string, since time.Time) []Event {
+	scope := []string{}
+	if bootID != "" {
+		scope = append(scope, "-b", bootID)
+	}
+	if !since.IsZero() {
+		scope = append(scope, fmt.Sprintf("--since=@%d", since.Unix()))
+	}
+
+	output, err := j.journalctl(append(scope, "--no-pager", "-o", "short-iso", "MESSAGE_ID="+timeChangeMessageID)...)
+	if err != nil {
+		return nil
+	}
+
+	events := []Event{}
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.Contains(line, "Time has been changed") {
+			continue
+		}
+
+		timestamp, ok := parseLineTimestamp(line)
+		if !ok {
+			continue
+		}
+		events = append(events, Event{Timestamp: timestamp, Type: "clock"})
+	}
+	return events
+}
+
+// lineTimestampRegex matches the timestamp of a short-iso line. Depending on
+// the journald configuration seconds may carry a fraction, e.g.
+// 2025-10-28T16:28:42.123456+01:00
+var lineTimestampRegex = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?[+-]\d{2}:\d{2})`)
+
+// parseLineTimestamp parses the timestamp at the start of a short-iso line
+func parseLineTimestamp(line string) (time.Time, bool) {
+	matches := lineTimestampRegex.FindStringSubmatch(line)
+	if len(matches) < 2 {
+		return time.Time{}, false
+	}
+	timestamp, err := time.Parse("2006-01-02T15:04:05.999999999-07:00", matches[1])
+	return timestamp, err == nil
+}
+
+// sleepMarkers are the systemd messages around a suspend or hibernation
+var sleepMarkers = []struct {
+	marker    string
+	eventType string
+}{
+	{"Starting System Suspend", "suspend"},
+	{"Finished System Suspend", "resume"},
+	{"Starting System Hibernate", "hibernate"},
+	{"Finished System Hibernate", "resume"},
+}
+
+// parseSleepLines reads the suspend, hibernate and resume events from
+// short-iso journal lines, other lines are ignored
+func parseSleepLines(output []byte) []Event {
+	events := []Event{}
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		line := scanner.Text()
+		for _, sleep := range sleepMarkers {
+			if !strings.Contains(line, sleep.marker) {
+				continue
+			}
+			if timestamp, ok := parseLineTimestamp(line); ok {
+				events = append(events, Event{Timestamp: timestamp, Type: sleep.eventType})
+			}
+			break
+		}
+	}
+	return events
+}
+
+// shutdownInitiator guesses who requested the shutdown at the end of the
+// boot: "user:<name>" for a shutdown command run through sudo or
+// "power-button" when logind saw the power key. It returns an empty string
+// when nothing was found.
+func (j *Journal) shutdownInitiator(bootID string) string {
+	output, err := j.journalctl("-b", bootID, "--no-pager", "-o", "short-iso", "-t", "sudo", "-t", "systemd-logind")
+	if err != nil {
+		return ""
+	}
+
+	// Format: 2025-10-30T00:14:30+01:00 host sudo[4242]:     root : TTY=pts/0 ; PWD=/root ; USER=root ; COMMAND=/usr/sbin/poweroff
+	sudoRegex := regexp.MustCompile(`sudo\[\d+\]:\s+(\S+) : .*COMMAND=.*\b(shutdown|poweroff|reboot|halt)\b`)
+
+	initiator := ""
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// The last request before the end of the boot wins
+		if matches := sudoRegex.FindStringSubmatch(line); len(matches) == 3 {
+			initiator = "user:" + matches[1]
+		} else if strings.Contains(line, "Power key pressed") {
+			initiator = "power-button"
+		}
+	}
+
+	return initiator
+}
+
+// joinReasons joins the non-empty reasons with commas
+func joinReasons(reasons ...string) string {
+	result := []string{}
+	for _, reason := range reasons {
+		if reason != "" {
+			result = append(result, reason)
+		}
+	}
+	return strings.Join(result, ", ")
+}
+
+// detectSuspendResumePerBoot runs detectSuspendResume for every boot with at
+// most workers queries at a time, so journald is not hammered
+func (j *Journal) detectSuspendResumePerBoot(boots []journalBoot, workers int) []Event {
+	results := make([][]Event, len(boots))
+
+	var group errgroup.Group
+	group.SetLimit(workers)
+	for i, boot := range boots {
+		group.Go(func() error {
+			results[i] = j.detectSuspendResume(boot.ID, time.Time{})
+			return nil
+		})
+	}
+	group.Wait()
+
+	events := []Event{}
+	for _, result := range results {
+		events = append(events, result...)
+	}
+	return events
+}
+
+// detectSuspendResume reads the suspend and hibernate events of one boot, or
+// of all boots if bootID is empty. A non-zero since skips older entries.
+func (j *Journal) detectSuspendResume(bootID string, since time.Time) []Event {
+	events := []Event{}
+
+	scope := []string{}
+	if bootID != "" {
+		scope = append(scope, "-b", bootID)
+	}
+	if !since.IsZero() {
+		scope = append(scope, fmt.Sprintf("--since=@%d", since.Unix()))
+	}
+
+	for _, unit := range []string{"systemd-suspend.service", "systemd-hibernate.service"} {
+		output, err := j.journalctl(append(scope, "--no-pager", "-o", "short-iso", "-u", unit)...)
+		if unitNotFound(output, err) {
+			// Systems without suspend support have no such unit
+			continue
+		}
+		if err != nil {
+			// The boots are still reported, just without these sleeps
+			j.logf("cannot read %s, skipping its sleeps: %v", unit, err)
+			continue
+		}
+		events = append(events, parseSleepLines(output)...)
+	}
+
+	return events
+}
+
+// unitNotFoundMessages are what journalctl says when a unit never logged
+// anything, as opposed to the journal not being readable
+var unitNotFoundMessages = []string{"No entries", "No such unit"}
+
+// unitNotFound reports whether journalctl failed only because the unit it
+// was asked for has no entries
+func unitNotFound(output []byte, err error) bool {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return false
+	}
+	for _, message := range unitNotFoundMessages {
+		if strings.Contains(err.Error(), message) || strings.Contains(string(output), message) {
+			return true
+		}
+	}
+	return false
+}
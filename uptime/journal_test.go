@@ -0,0 +1,356 @@
+package uptime
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	listBootsJSON    = "journalctl --list-boots --no-pager --output=json"
+	listBootsText    = "journalctl --list-boots --no-pager --output=short-iso"
+	suspendCommand   = "journalctl --no-pager -o short-iso -u systemd-suspend.service"
+	hibernateCommand = "journalctl --no-pager -o short-iso -u systemd-hibernate.service"
+	clockCommand     = "journalctl --no-pager -o short-iso MESSAGE_ID=c7a787079b354eaaa9e77b371893cd27"
+)
+
+// Boot IDs of the recorded boot list
+const (
+	poweredOffBoot = "3460c36536374bb48bb910bae80c34b6"
+	crashedBoot    = "4460c36536374bb48bb910bae80c34b7"
+	rebootedBoot   = "5460c36536374bb48bb910bae80c34b8"
+	currentBoot    = "6460c36536374bb48bb910bae80c34b9"
+)
+
+// fixture returns the content of a file in testdata
+func fixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// shutdownCommand is the query of shutdownReason for a boot
+func shutdownCommand(bootID string) string {
+	return "journalctl -b " + bootID + " --no-pager -o short-iso -u shutdown.target -u reboot.target -u apt-daily-upgrade.service"
+}
+
+// recordedJournal serves the recorded fixtures: a power off, a crash, a
+// reboot and the current boot, with a suspend in the first boot
+func recordedJournal(t *testing.T) map[string]string {
+	return map[string]string{
+		shutdownCommand(poweredOffBoot): fixture(t, "shutdown-target.txt"),
+		shutdownCommand(crashedBoot):    "",
+		shutdownCommand(rebootedBoot):   fixture(t, "reboot-target.txt"),
+		suspendCommand:                  fixture(t, "suspend.txt"),
+		hibernateCommand:                "",
+		clockCommand:                    "",
+	}
+}
+
+// testNow is when the recorded journal is read, the last entry of the
+// current boot
+var testNow = time.Date(2025, 11, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+
+// recordedAt returns a journal of outputs read at now
+func recordedAt(outputs map[string]string, now time.Time) *Journal {
+	journal := NewJournal(FakeRunner{Outputs: outputs})
+	journal.Now = func() time.Time { return now }
+	return journal
+}
+
+// formatEvents prints events one per line in UTC, so expectations do not
+// depend on the zone they were parsed in
+func formatEvents(events []Event) string {
+	lines := []string{}
+	for _, event := range events {
+		line := fmt.Sprintf("%s %s %s", event.Timestamp.UTC().Format(time.RFC3339Nano), event.Type, event.BootID)
+		if event.Reason != "" {
+			line += " (" + event.Reason + ")"
+		}
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatSessions prints sessions one per line in UTC
+func formatSessions(sessions []Session) string {
+	lines := []string{}
+	for _, session := range sessions {
+		lines = append(lines, fmt.Sprintf("%s - %s %s", session.Start.UTC().Format(time.RFC3339Nano),
+			session.End.UTC().Format(time.RFC3339Nano), session.Type))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestJournalEvents(t *testing.T) {
+	recorded := strings.Join([]string{
+		"2025-10-28T15:28:42Z boot " + poweredOffBoot,
+		"2025-10-29T11:00:00Z suspend",
+		"2025-10-29T12:00:00.123456Z resume",
+		"2025-10-29T23:14:40Z shutdown " + poweredOffBoot,
+		"2025-10-30T07:00:00Z boot " + crashedBoot,
+		"2025-10-30T17:00:00Z crash " + crashedBoot,
+		"2025-10-31T08:00:00Z boot " + rebootedBoot,
+		"2025-10-31T19:00:00Z shutdown " + rebootedBoot + " (reboot)",
+		"2025-11-01T08:00:00Z boot " + currentBoot,
+	}, "\n")
+
+	tests := []struct {
+		name     string
+		command  string
+		fixture  string
+		now      time.Time
+		expected string
+	}{
+		{"text boot list", listBootsText, "list-boots.txt", testNow, recorded},
+		{"JSON boot list", listBootsJSON, "list-boots.json", testNow, recorded},
+		{"text boot list without a header", listBootsText, "list-boots-noheader.txt", testNow, recorded},
+		{"current boot without a last entry", listBootsText, "list-boots-current.txt", testNow, recorded},
+		{"numeric zone offsets", listBootsText, "list-boots-offsets.txt", time.Date(2025, 10, 30, 17, 0, 0, 0, time.UTC), strings.Join([]string{
+			"2025-10-28T15:28:42Z boot " + poweredOffBoot,
+			"2025-10-29T11:00:00Z suspend",
+			"2025-10-29T12:00:00.123456Z resume",
+			"2025-10-29T23:14:40Z shutdown " + poweredOffBoot,
+			"2025-10-30T07:00:00Z boot " + crashedBoot,
+		}, "\n")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			outputs := recordedJournal(t)
+			outputs[test.command] = fixture(t, test.fixture)
+
+			events, err := recordedAt(outputs, test.now).Events()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual := formatEvents(events); actual != test.expected {
+				t.Errorf("unexpected events:\n%s\nexpected:\n%s", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestJournalSessions(t *testing.T) {
+	outputs := recordedJournal(t)
+	outputs[listBootsText] = fixture(t, "list-boots.txt")
+	events, err := recordedAt(outputs, testNow).Events()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := CalculateSessionsAt(DeduplicateEvents(events, 2*time.Minute), testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.Join([]string{
+		"2025-10-28T15:28:42Z - 2025-10-29T11:00:00Z boot → suspend",
+		"2025-10-29T12:00:00.123456Z - 2025-10-29T23:14:40Z resume → shutdown",
+		"2025-10-30T07:00:00Z - 2025-10-30T17:00:00Z boot → crash",
+		"2025-10-31T08:00:00Z - 2025-10-31T19:00:00Z boot → shutdown (reboot)",
+		"2025-11-01T08:00:00Z - 2025-11-01T11:00:00Z boot → (still active)",
+	}, "\n")
+	if actual := formatSessions(sessions); actual != expected {
+		t.Fatalf("unexpected sessions:\n%s\nexpected:\n%s", actual, expected)
+	}
+	if sessions[0].Asleep != time.Hour+123456*time.Microsecond {
+		t.Errorf("expected the first session to sleep for an hour, got %s", sessions[0].Asleep)
+	}
+}
+
+func TestParseBootsText(t *testing.T) {
+	tests := []struct {
+		name     string
+		fixture  string
+		start    string
+		duration time.Duration
+	}{
+		{"zone abbreviation", "list-boots.txt", "2025-10-28T15:28:42Z", 31*time.Hour + 45*time.Minute + 58*time.Second},
+		{"DST fall-back", "list-boots-dst.txt", "2025-10-26T00:30:00Z", 45 * time.Minute},
+		{"offset without colon", "list-boots-offsets.txt", "2025-10-28T15:28:42Z", 31*time.Hour + 45*time.Minute + 58*time.Second},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			boots := parseBootsText([]byte(fixture(t, test.fixture)), testNow)
+			if len(boots) == 0 {
+				t.Fatal("no boots parsed")
+			}
+			boot := boots[0]
+			if start := boot.StartTime.UTC().Format(time.RFC3339); start != test.start {
+				t.Errorf("expected start %s, got %s", test.start, start)
+			}
+			if duration := boot.EndTime.Sub(boot.StartTime); duration != test.duration {
+				t.Errorf("expected duration %s, got %s", test.duration, duration)
+			}
+		})
+	}
+}
+
+func TestParseBootsTextCurrentBoot(t *testing.T) {
+	boots := parseBootsText([]byte(fixture(t, "list-boots-current.txt")), testNow)
+	if len(boots) != 4 {
+		t.Fatalf("expected 4 boots, got %d", len(boots))
+	}
+	current := boots[len(boots)-1]
+	if current.ID != currentBoot || !current.EndTime.Equal(testNow) {
+		t.Errorf("expected the current boot to last until now, got %s until %s", current.ID, current.EndTime)
+	}
+}
+
+func TestIsCurrentBoot(t *testing.T) {
+	tests := []struct {
+		name      string
+		directory string
+		boot      journalBoot
+		expected  bool
+	}{
+		{"current boot", "", journalBoot{Index: 0, EndTime: testNow.Add(-time.Hour)}, true},
+		{"previous boot", "", journalBoot{Index: -1, EndTime: testNow}, false},
+		{"exported journal written recently", "/var/log/journal/remote", journalBoot{Index: 0, EndTime: testNow.Add(-4 * time.Minute)}, true},
+		{"exported journal written long ago", "/var/log/journal/remote", journalBoot{Index: 0, EndTime: testNow.Add(-6 * time.Minute)}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			journal := recordedAt(nil, testNow)
+			journal.Directory = test.directory
+			journal.CurrentBootCutoff = 5 * time.Minute
+			if actual := journal.isCurrentBoot(test.boot); actual != test.expected {
+				t.Errorf("expected %t, got %t", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDetectSuspendResumeFractionalSeconds(t *testing.T) {
+	journal := NewJournal(FakeRunner{Outputs: map[string]string{
+		suspendCommand:   fixture(t, "suspend.txt"),
+		hibernateCommand: "",
+	}})
+
+	events := journal.detectSuspendResume("", time.Time{})
+	if len(events) != 2 {
+		t.Fatalf("expected a suspend and a resume, got %+v", events)
+	}
+	resume := time.Date(2025, 10, 29, 12, 0, 0, 123456000, time.UTC)
+	if events[1].Type != "resume" || !events[1].Timestamp.Equal(resume) {
+		t.Errorf("expected a resume at %s, got %s at %s", resume, events[1].Type, events[1].Timestamp)
+	}
+}
+
+func TestHibernateWakeBoot(t *testing.T) {
+	journal := recordedAt(map[string]string{
+		listBootsText:                   fixture(t, "list-boots-hibernate.txt"),
+		shutdownCommand(poweredOffBoot): "",
+		suspendCommand:                  "",
+		hibernateCommand:                fixture(t, "hibernate.txt"),
+		clockCommand:                    "",
+	}, time.Date(2025, 10, 30, 17, 0, 0, 0, time.UTC))
+
+	events, err := journal.Events()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := CalculateSessionsAt(DeduplicateEvents(events, 2*time.Minute), journal.now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The wake boot continues the session that hibernated instead of ending
+	// it in a crash
+	expected := strings.Join([]string{
+		"2025-10-28T15:28:42Z - 2025-10-29T17:00:00Z boot → hibernate",
+		"2025-10-30T07:00:00Z - 2025-10-30T17:00:00Z resume → (still active)",
+	}, "\n")
+	if actual := formatSessions(sessions); actual != expected {
+		t.Fatalf("unexpected sessions:\n%s\nexpected:\n%s", actual, expected)
+	}
+	if sessions[0].Asleep != 14*time.Hour {
+		t.Errorf("expected the first session to sleep for 14h, got %s", sessions[0].Asleep)
+	}
+}
+
+func TestJournalClockChange(t *testing.T) {
+	outputs := recordedJournal(t)
+	outputs[listBootsText] = fixture(t, "list-boots.txt")
+	outputs[clockCommand] = fixture(t, "clock-change.txt")
+	events, err := recordedAt(outputs, testNow).Events()
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := formatEvents(events[len(events)-1:])
+	if last != "2025-11-01T08:00:05Z clock" {
+		t.Errorf("expected the clock step last, got %s", last)
+	}
+
+	sessions, err := CalculateSessionsAt(DeduplicateEvents(events, 2*time.Minute), testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, session := range sessions {
+		if expected := i == len(sessions)-1; session.ClockAdjusted != expected {
+			t.Errorf("expected ClockAdjusted %t for %s %s", expected, session.Start.UTC(), session.Type)
+		}
+	}
+}
+
+func TestUnitNotFound(t *testing.T) {
+	exitErr := &exec.ExitError{}
+	tests := []struct {
+		name     string
+		output   string
+		err      error
+		expected bool
+	}{
+		{"no entries in the error", "", fmt.Errorf("%w: -- No entries --", exitErr), true},
+		{"no entries in the output", "-- No entries --", exitErr, true},
+		{"missing unit", "Failed to add filter: No such unit", exitErr, true},
+		{"permission denied", "Failed to open journal: Permission denied", exitErr, false},
+		{"journalctl not started", "", errors.New("No entries"), false},
+		{"success", "-- No entries --", nil, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := unitNotFound([]byte(test.output), test.err); actual != test.expected {
+				t.Errorf("expected %t, got %t", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestShutdownReason(t *testing.T) {
+	upgraded := "2025-10-31T19:59:50+01:00 host unattended-upgrade[2112]: Found /var/run/reboot-required, rebooting\n"
+	stopped := "2025-10-31T19:59:58+01:00 host systemd[1]: Stopped unattended-upgrades.service - Unattended Upgrades Shutdown.\n"
+	tests := []struct {
+		name     string
+		output   string
+		reason   string
+		shutdown bool
+	}{
+		{"power off", fixture(t, "shutdown-target.txt"), "", true},
+		{"reboot", fixture(t, "reboot-target.txt"), "reboot", true},
+		{"reboot with unattended-upgrades installed", stopped + fixture(t, "reboot-target.txt"), "reboot", true},
+		{"reboot by unattended-upgrades", upgraded + fixture(t, "reboot-target.txt"), "planned reboot", true},
+		{"crash", "", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			journal := recordedAt(map[string]string{shutdownCommand(rebootedBoot): test.output}, testNow)
+			shutdown, reason := journal.shutdownReason(rebootedBoot)
+			if shutdown != test.shutdown || reason != test.reason {
+				t.Errorf("expected %t %q, got %t %q", test.shutdown, test.reason, shutdown, reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package uptime
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// sessionJSON is the stable JSON form of a Session. Consumers depend on
+// these field names, so they must not change.
+type sessionJSON struct {
+	Start           string `json:"start"`
+	End             string `json:"end"`
+	DurationSeconds int64  `json:"duration_seconds"`
+	AsleepSeconds   int64  `json:"asleep_seconds"`
+	Type            string `json:"type"`
+	BootID          string `json:"boot_id,omitempty"`
+	Host            string `json:"host,omitempty"`
+	ClockAdjusted   bool   `json:"clock_adjusted,omitempty"`
+	// Suspends and AsleepWithinSeconds are set for merged sessions only
+	Suspends            int   `json:"suspends,omitempty"`
+	AsleepWithinSeconds int64 `json:"asleep_within_seconds,omitempty"`
+}
+
+// MarshalJSON encodes the session with snake_case field names, RFC 3339
+// timestamps and the duration in whole seconds.
+func (s Session) MarshalJSON() ([]byte, error) {
+	return json.Marshal(sessionJSON{
+		Start:           s.Start.Format(time.RFC3339),
+		End:             s.End.Format(time.RFC3339),
+		DurationSeconds: int64(s.Duration.Seconds()),
+		AsleepSeconds:   int64(s.Asleep.Seconds()),
+		Type:            s.Type,
+		BootID:          s.BootID,
+		Host:            s.Host,
+		ClockAdjusted:   s.ClockAdjusted,
+
+		Suspends:            s.Suspends,
+		AsleepWithinSeconds: int64(s.AsleepWithin.Seconds()),
+	})
+}
+
+// UnmarshalJSON decodes a session written by MarshalJSON
+func (s *Session) UnmarshalJSON(data []byte) error {
+	var decoded sessionJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	start, err := time.Parse(time.RFC3339, decoded.Start)
+	if err != nil {
+		return fmt.Errorf("invalid session start: %v", err)
+	}
+	end, err := time.Parse(time.RFC3339, decoded.End)
+	if err != nil {
+		return fmt.Errorf("invalid session end: %v", err)
+	}
+
+	*s = Session{
+		Start:    start,
+		End:      end,
+		Duration: time.Duration(decoded.DurationSeconds) * time.Second,
+		Asleep:   time.Duration(decoded.AsleepSeconds) * time.Second,
+		Type:     decoded.Type,
+		BootID:   decoded.BootID,
+		Host:     decoded.Host,
+
+		ClockAdjusted: decoded.ClockAdjusted,
+
+		Suspends:     decoded.Suspends,
+		AsleepWithin: time.Duration(decoded.AsleepWithinSeconds) * time.Second,
+	}
+	return nil
+}
+
+// eventJSON is the stable JSON form of an Event
+type eventJSON struct {
+	Timestamp string `json:"timestamp"`
+	Type      string `json:"type"`
+	BootID    string `json:"boot_id,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	Host      string `json:"host,omitempty"`
+}
+
+// MarshalJSON encodes the event with snake_case field names and an RFC 3339
+// timestamp.
+func (e Event) MarshalJSON() ([]byte, error) {
+	return json.Marshal(eventJSON{
+		Timestamp: e.Timestamp.Format(time.RFC3339Nano),
+		Type:      e.Type,
+		BootID:    e.BootID,
+		Reason:    e.Reason,
+		Host:      e.Host,
+	})
+}
+
+// UnmarshalJSON decodes an event written by MarshalJSON
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var decoded eventJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	timestamp, err := time.Parse(time.RFC3339Nano, decoded.Timestamp)
+	if err != nil {
+		return fmt.Errorf("invalid event timestamp: %v", err)
+	}
+
+	*e = Event{
+		Timestamp: timestamp,
+		Type:      decoded.Type,
+		BootID:    decoded.BootID,
+		Reason:    decoded.Reason,
+		Host:      decoded.Host,
+	}
+	return nil
+}
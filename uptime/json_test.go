@@ -0,0 +1,66 @@
+package uptime
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSessionJSON(t *testing.T) {
+	cet := time.FixedZone("CET", 3600)
+	session := Session{
+		Start:    time.Date(2025, 10, 28, 16, 28, 42, 0, cet),
+		End:      time.Date(2025, 10, 29, 12, 0, 0, 0, cet),
+		Duration: 19*time.Hour + 31*time.Minute + 18*time.Second,
+		Type:     "boot → suspend",
+		Asleep:   time.Hour,
+		BootID:   "3460c36536374bb48bb910bae80c34b6",
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"start":"2025-10-28T16:28:42+01:00","end":"2025-10-29T12:00:00+01:00","duration_seconds":70278,"asleep_seconds":3600,"type":"boot → suspend","boot_id":"3460c36536374bb48bb910bae80c34b6"}`
+	if string(data) != expected {
+		t.Errorf("unexpected JSON:\n%s\nexpected:\n%s", data, expected)
+	}
+
+	var decoded Session
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Start.Equal(session.Start) || !decoded.End.Equal(session.End) ||
+		decoded.Duration != session.Duration || decoded.Asleep != session.Asleep ||
+		decoded.Type != session.Type || decoded.BootID != session.BootID {
+		t.Errorf("round trip changed the session:\n%+v\nexpected:\n%+v", decoded, session)
+	}
+}
+
+func TestMergedSessionJSON(t *testing.T) {
+	session := Session{
+		Start:        time.Date(2025, 10, 28, 8, 0, 0, 0, time.UTC),
+		End:          time.Date(2025, 10, 28, 18, 0, 0, 0, time.UTC),
+		Duration:     9 * time.Hour,
+		Type:         "boot → shutdown",
+		Suspends:     2,
+		AsleepWithin: time.Hour,
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"start":"2025-10-28T08:00:00Z","end":"2025-10-28T18:00:00Z","duration_seconds":32400,"asleep_seconds":0,"type":"boot → shutdown","suspends":2,"asleep_within_seconds":3600}`
+	if string(data) != expected {
+		t.Errorf("unexpected JSON:\n%s\nexpected:\n%s", data, expected)
+	}
+
+	var decoded Session
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Suspends != 2 || decoded.AsleepWithin != time.Hour {
+		t.Errorf("round trip lost the merged suspends: %+v", decoded)
+	}
+}
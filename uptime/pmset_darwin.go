@@ -0,0 +1,106 @@
+//go:build darwin
+
+package uptime
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Pmset reads sleep and wake history from the macOS power management log
+// and the time of the current boot from the kernel.
+type Pmset struct {
+	runner CommandRunner
+}
+
+func NewPmset(runner CommandRunner) EventSource {
+	return &Pmset{runner: runner}
+}
+
+func (p *Pmset) Events() ([]Event, error) {
+	events := []Event{}
+
+	// Format: { sec = 1698506922, usec = 123456 } Sat Oct 28 16:28:42 2023
+	bootOutput, err := p.runner.Run("sysctl", "-n", "kern.boottime")
+	if err != nil {
+		return nil, fmt.Errorf("cannot read boot time: %v", err)
+	}
+
+	var bootTime time.Time
+	secRegex := regexp.MustCompile(`sec = (\d+)`)
+	if matches := secRegex.FindStringSubmatch(string(bootOutput)); len(matches) == 2 {
+		sec, err := strconv.ParseInt(matches[1], 10, 64)
+		if err == nil {
+			bootTime = time.Unix(sec, 0)
+			events = append(events, Event{
+				Timestamp: bootTime,
+				Type:      "boot",
+			})
+		}
+	}
+
+	logOutput, err := p.runner.Run("pmset", "-g", "log")
+	if err != nil {
+		return nil, fmt.Errorf("cannot read power management log: %v", err)
+	}
+
+	// The log reaches back into earlier boots, which it has no shutdown
+	// entries for. They ended at the last line logged before this boot.
+	var lastBeforeBoot time.Time
+
+	scanner := bufio.NewScanner(strings.NewReader(string(logOutput)))
+	for scanner.Scan() {
+		line := scanner.Text()
+		// Format: 2023-10-28 18:00:00 +0200 Sleep               	Entering Sleep state due to 'Clamshell Sleep'...
+		//         2023-10-28 19:00:00 +0200 Wake                	DarkWake to FullWake from Deep Idle...
+
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+
+		timestamp, err := time.Parse("2006-01-02 15:04:05 -0700", strings.Join(fields[:3], " "))
+		if err != nil {
+			continue
+		}
+		if timestamp.Before(bootTime) {
+			lastBeforeBoot = timestamp
+		}
+
+		eventType := ""
+		switch fields[3] {
+		case "Sleep":
+			eventType = "suspend"
+		case "Wake":
+			eventType = "resume"
+		default:
+			// DarkWake is a maintenance wake-up with the display off, it
+			// does not start a session
+			continue
+		}
+
+		events = append(events, Event{
+			Timestamp: timestamp,
+			Type:      eventType,
+		})
+	}
+
+	if !lastBeforeBoot.IsZero() {
+		events = append(events, Event{
+			Timestamp: lastBeforeBoot,
+			Type:      "shutdown",
+		})
+	}
+
+	// The shutdown stays after a sleep logged at the same time
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+
+	return events, nil
+}
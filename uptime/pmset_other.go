@@ -0,0 +1,8 @@
+//go:build !darwin
+
+package uptime
+
+// NewPmset returns nil, the power management log only exists on macOS
+func NewPmset(runner CommandRunner) EventSource {
+	return nil
+}
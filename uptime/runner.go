@@ -0,0 +1,68 @@
+package uptime
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// CommandRunner executes an external command and returns its standard output.
+type CommandRunner interface {
+	Run(name string, args ...string) ([]byte, error)
+}
+
+// ExecRunner runs commands on the local system. Only standard output is
+// returned, warnings on standard error are passed to Logf.
+type ExecRunner struct {
+	// Context kills a command still running when it is done, nil never does
+	Context context.Context
+	// Logf receives every line the command writes to standard error, nil
+	// discards them
+	Logf func(format string, args ...any)
+}
+
+func (r ExecRunner) Run(name string, args ...string) ([]byte, error) {
+	ctx := r.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+
+	message := strings.TrimSpace(stderr.String())
+	if r.Logf != nil && message != "" {
+		for _, line := range strings.Split(message, "\n") {
+			r.Logf("%s: %s", name, line)
+		}
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && message != "" {
+		// The last line usually says what went wrong
+		lines := strings.Split(message, "\n")
+		return output, fmt.Errorf("%w: %s", err, lines[len(lines)-1])
+	}
+	return output, err
+}
+
+// FakeRunner returns canned output keyed by the full command line, e.g.
+// "journalctl --list-boots --no-pager --output=short-iso".
+type FakeRunner struct {
+	Outputs map[string]string
+}
+
+func (f FakeRunner) Run(name string, args ...string) ([]byte, error) {
+	commandLine := strings.Join(append([]string{name}, args...), " ")
+
+	output, ok := f.Outputs[commandLine]
+	if !ok {
+		return nil, fmt.Errorf("no canned output for %q", commandLine)
+	}
+	return []byte(output), nil
+}
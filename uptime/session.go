@@ -0,0 +1,329 @@
+// Package uptime reconstructs work sessions of a computer from its boot,
+// shutdown, suspend and resume history.
+package uptime
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Event is a single boot, shutdown, crash, suspend, hibernate or resume
+// record. A "clock" event marks a step of the system clock.
+type Event struct {
+	Timestamp time.Time
+	Type      string
+	// BootID is the journal boot the event belongs to, if known
+	BootID string
+	// Reason optionally explains the event, e.g. "reboot" for a shutdown
+	Reason string
+	// Host names the machine the event was read from when several are
+	// reported together
+	Host string
+}
+
+// Session is a period of time the machine was up.
+type Session struct {
+	Start    time.Time
+	End      time.Time
+	Duration time.Duration
+	Type     string
+	// Asleep is the time spent suspended or hibernated between the end of
+	// this session and the following resume.
+	Asleep time.Duration
+	// BootID is the journal boot the session belongs to, if known
+	BootID string
+	// Host is the machine of the session, see Event.Host
+	Host string
+	// ClockAdjusted is set when the system clock was stepped during the
+	// session, so its duration may be wrong
+	ClockAdjusted bool
+	// Suspends and AsleepWithin describe the sleep cycles inside a session
+	// built by MergeSuspends
+	Suspends     int
+	AsleepWithin time.Duration
+}
+
+// wakeBootWindow is how close a boot and a resume must be to be one wake up.
+// Some kernels log a spurious boot when waking from hibernation.
+const wakeBootWindow = 5 * time.Second
+
+// DeduplicateEvents collapses repeated events of the same type. Events that
+// carry a boot ID are duplicates only when they belong to the same boot,
+// others when they fall within the given window. A boot and a resume within
+// wakeBootWindow of each other become a single resume.
+func DeduplicateEvents(events []Event, window time.Duration) []Event {
+	if len(events) == 0 {
+		return events
+	}
+
+	result := []Event{events[0]}
+
+	for i := 1; i < len(events); i++ {
+		lastEvent := result[len(result)-1]
+		currentEvent := events[i]
+
+		if currentEvent.Type == lastEvent.Type {
+			// Two quick reboots are still two different boots
+			if currentEvent.BootID != "" && lastEvent.BootID != "" {
+				if currentEvent.BootID == lastEvent.BootID {
+					continue
+				}
+			} else if currentEvent.Timestamp.Sub(lastEvent.Timestamp) < window {
+				continue
+			}
+		}
+
+		if collapsed, ok := collapseWakeBoot(result, currentEvent); ok {
+			result = collapsed
+			continue
+		}
+
+		result = append(result, currentEvent)
+	}
+
+	return result
+}
+
+// collapseWakeBoot merges event with a boot or resume at the end of events
+// that belongs to the same wake up into a single resume. The crash the
+// spurious boot implies for the boot that went to sleep is dropped too.
+func collapseWakeBoot(events []Event, event Event) ([]Event, bool) {
+	isEndOfBoot := func(i int) bool {
+		return i >= 0 && events[i].Type == "crash" && event.Timestamp.Sub(events[i].Timestamp) <= wakeBootWindow
+	}
+
+	i := len(events) - 1
+	if isEndOfBoot(i) {
+		i--
+	}
+	if i < 0 || !isWakeBoot(events[i], event) {
+		return events, false
+	}
+
+	resume := events[i]
+	if event.Type == "resume" {
+		resume = event
+	}
+	result := events[:i]
+	if isEndOfBoot(i - 1) {
+		result = events[:i-1]
+	}
+	return append(result, resume), true
+}
+
+// isWakeBoot reports whether two consecutive events are a boot and a resume
+// of the same wake up, in either order
+func isWakeBoot(a, b Event) bool {
+	if !(a.Type == "boot" && b.Type == "resume") && !(a.Type == "resume" && b.Type == "boot") {
+		return false
+	}
+	return b.Timestamp.Sub(a.Timestamp) <= wakeBootWindow
+}
+
+// CalculateSessions turns a chronological list of events into the periods
+// the machine was up. Events must be sorted by time and reach the present,
+// the last of them decides whether the machine is up or asleep right now.
+func CalculateSessions(events []Event) ([]Session, error) {
+	return CalculateSessionsAt(events, time.Now())
+}
+
+// CalculateSessionsAt is CalculateSessions with the current time given, it
+// is where a session that is still active ends.
+func CalculateSessionsAt(events []Event, now time.Time) ([]Session, error) {
+	sessions := []Session{}
+
+	for i := 1; i < len(events); i++ {
+		if events[i].Timestamp.Before(events[i-1].Timestamp) {
+			return nil, fmt.Errorf("events are not in chronological order: %s %s is before %s %s",
+				events[i].Type, events[i].Timestamp.Format(time.RFC3339),
+				events[i-1].Type, events[i-1].Timestamp.Format(time.RFC3339),
+			)
+		}
+	}
+
+	var sessionStart *Event
+	var sessionType string
+
+	// Suspend and resume events do not know their boot, they inherit it
+	bootID := ""
+
+	// Index of the session after which the machine went to sleep
+	asleepAfter := -1
+
+	// Whether the clock was stepped since the session started
+	clockAdjusted := false
+
+	for i := 0; i < len(events); i++ {
+		event := events[i]
+
+		switch event.Type {
+		case "clock":
+			clockAdjusted = clockAdjusted || sessionStart != nil
+
+		case "boot", "resume":
+			// Waking up closes the sleep period, booting instead means the
+			// machine never woke up
+			if event.Type == "resume" && asleepAfter >= 0 {
+				sessions[asleepAfter].Asleep = event.Timestamp.Sub(sessions[asleepAfter].End)
+			} else if asleepAfter >= 0 {
+				sessions = append(sessions, failedSuspend(sessions[asleepAfter], event.Timestamp))
+			}
+			asleepAfter = -1
+
+			// A new activity session begins
+			if sessionStart != nil {
+				// Close previous session (was improperly terminated)
+				endType := event.Type
+				if event.Type == "boot" {
+					endType = "crash"
+				}
+
+				sessions = append(sessions, Session{
+					Start:    sessionStart.Timestamp,
+					End:      event.Timestamp,
+					Duration: event.Timestamp.Sub(sessionStart.Timestamp),
+					Type:     sessionType + " → " + endType,
+					BootID:   bootID,
+					Host:     sessionStart.Host,
+
+					ClockAdjusted: clockAdjusted,
+				})
+			}
+			if event.Type == "boot" {
+				bootID = event.BootID
+			}
+			// Point into the slice rather than at the loop variable, so the
+			// start survives the following iterations on any Go version
+			sessionStart = &events[i]
+			clockAdjusted = false
+			if event.Type == "boot" {
+				sessionType = "boot"
+			} else {
+				sessionType = "resume"
+			}
+
+		case "shutdown", "suspend", "hibernate", "crash":
+			// Activity session ends
+			if sessionStart != nil {
+				endType := ""
+				switch event.Type {
+				case "shutdown":
+					endType = "shutdown"
+				case "suspend":
+					endType = "suspend"
+				case "hibernate":
+					endType = "hibernate"
+				case "crash":
+					endType = "crash"
+				}
+				if event.Reason != "" {
+					endType += " (" + event.Reason + ")"
+				}
+
+				sessions = append(sessions, Session{
+					Start:    sessionStart.Timestamp,
+					End:      event.Timestamp,
+					Duration: event.Timestamp.Sub(sessionStart.Timestamp),
+					Type:     sessionType + " → " + endType,
+					BootID:   bootID,
+					Host:     sessionStart.Host,
+
+					ClockAdjusted: clockAdjusted,
+				})
+				sessionStart = nil
+				sessionType = ""
+
+				if event.Type == "suspend" || event.Type == "hibernate" {
+					asleepAfter = len(sessions) - 1
+				}
+			} else if event.Type == "shutdown" || event.Type == "crash" {
+				// The boot ended while the machine was asleep. Crashing
+				// there means the suspend failed.
+				if event.Type == "crash" && asleepAfter >= 0 {
+					sessions = append(sessions, failedSuspend(sessions[asleepAfter], event.Timestamp))
+				}
+				asleepAfter = -1
+			}
+		}
+	}
+
+	// Going to sleep without waking up means the machine is asleep right
+	// now, as long as the events reach the end of the journal and nothing
+	// ended the boot. The session has ended, so its duration must not grow.
+	if asleepAfter >= 0 {
+		sessions[asleepAfter].Type += " (asleep)"
+	}
+
+	// If there's an open session, mark as "still active"
+	if sessionStart != nil {
+		sessions = append(sessions, Session{
+			Start:    sessionStart.Timestamp,
+			End:      now,
+			Duration: now.Sub(sessionStart.Timestamp),
+			Type:     sessionType + " → (still active)",
+			BootID:   bootID,
+			Host:     sessionStart.Host,
+
+			ClockAdjusted: clockAdjusted,
+		})
+	}
+
+	return sessions, nil
+}
+
+// failedSuspend is the session between a suspend or hibernate that never
+// resumed and the end of its boot, e.g. "suspend → crash"
+func failedSuspend(asleep Session, end time.Time) Session {
+	return Session{
+		Start:    asleep.End,
+		End:      end,
+		Duration: end.Sub(asleep.End),
+		Type:     SessionEnd(asleep) + " → crash",
+		BootID:   asleep.BootID,
+		Host:     asleep.Host,
+	}
+}
+
+// MergeSuspends joins the sessions separated by a suspend or hibernate into
+// one session per boot. Its Duration stays the time awake, the time asleep
+// in between is kept in AsleepWithin.
+func MergeSuspends(sessions []Session) []Session {
+	return mergeSleeps(sessions, 0)
+}
+
+// MergeShortSuspends is MergeSuspends for the sleep cycles shorter than
+// minGap only, e.g. a twitchy lid switch
+func MergeShortSuspends(sessions []Session, minGap time.Duration) []Session {
+	if minGap <= 0 {
+		return sessions
+	}
+	return mergeSleeps(sessions, minGap)
+}
+
+// mergeSleeps joins sessions across sleeps shorter than maxAsleep, zero
+// joins across all of them
+func mergeSleeps(sessions []Session, maxAsleep time.Duration) []Session {
+	result := []Session{}
+	for _, session := range sessions {
+		if len(result) > 0 {
+			last := &result[len(result)-1]
+			end := SessionEnd(*last)
+			if (end == "suspend" || end == "hibernate") && SessionStart(session) == "resume" &&
+				session.BootID == last.BootID && session.Host == last.Host && !strings.HasSuffix(last.Type, "(asleep)") &&
+				(maxAsleep == 0 || last.Asleep < maxAsleep) {
+				_, sessionEnd, _ := strings.Cut(session.Type, " → ")
+				last.Type = SessionStart(*last) + " → " + sessionEnd
+				last.End = session.End
+				last.Duration += session.Duration
+				last.ClockAdjusted = last.ClockAdjusted || session.ClockAdjusted
+				last.Suspends++
+				last.AsleepWithin += last.Asleep
+				last.Asleep = session.Asleep
+				continue
+			}
+		}
+		result = append(result, session)
+	}
+	return result
+}
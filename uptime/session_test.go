@@ -0,0 +1,95 @@
+package uptime
+
+import (
+	"testing"
+	"time"
+)
+
+// at returns the given hour of the day the session tests run on
+func at(hour int) time.Time {
+	return time.Date(2025, 10, 28, hour, 0, 0, 0, time.UTC)
+}
+
+func TestCalculateSessions(t *testing.T) {
+	events := []Event{
+		{Timestamp: at(1), Type: "boot"},
+		{Timestamp: at(2), Type: "shutdown"},
+		{Timestamp: at(8), Type: "boot"},
+		{Timestamp: at(12), Type: "suspend"},
+		{Timestamp: at(14), Type: "resume"},
+		{Timestamp: at(18), Type: "shutdown"},
+		{Timestamp: at(20), Type: "boot"},
+		{Timestamp: at(21), Type: "boot"},
+		{Timestamp: at(23), Type: "shutdown"},
+	}
+
+	sessions, err := CalculateSessions(events)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every session must start at its own boot or resume, not at the
+	// last event seen
+	expected := []struct {
+		start, end int
+		kind       string
+	}{
+		{1, 2, "boot → shutdown"},
+		{8, 12, "boot → suspend"},
+		{14, 18, "resume → shutdown"},
+		{20, 21, "boot → crash"},
+		{21, 23, "boot → shutdown"},
+	}
+	if len(sessions) != len(expected) {
+		t.Fatalf("expected %d sessions, got %+v", len(expected), sessions)
+	}
+	for i, session := range sessions {
+		if !session.Start.Equal(at(expected[i].start)) || !session.End.Equal(at(expected[i].end)) || session.Type != expected[i].kind {
+			t.Errorf("session %d: expected %02d:00-%02d:00 %s, got %s-%s %s", i, expected[i].start, expected[i].end, expected[i].kind,
+				session.Start.Format("15:04"), session.End.Format("15:04"), session.Type)
+		}
+	}
+	if sessions[1].Asleep != 2*time.Hour {
+		t.Errorf("expected 2h asleep after the suspend, got %s", sessions[1].Asleep)
+	}
+}
+
+func TestCalculateSessionsOutOfOrder(t *testing.T) {
+	events := []Event{
+		{Timestamp: at(8), Type: "boot"},
+		{Timestamp: at(7), Type: "shutdown"},
+	}
+	if _, err := CalculateSessions(events); err == nil {
+		t.Error("expected an error for events out of order")
+	}
+}
+
+func TestCalculateSessionsAsleep(t *testing.T) {
+	tests := []struct {
+		name     string
+		events   []Event
+		expected string
+	}{
+		{"asleep now", []Event{
+			{Timestamp: at(8), Type: "boot"},
+			{Timestamp: at(12), Type: "suspend"},
+		}, "boot → suspend (asleep)"},
+		{"shut down while asleep", []Event{
+			{Timestamp: at(8), Type: "boot"},
+			{Timestamp: at(12), Type: "suspend"},
+			{Timestamp: at(14), Type: "shutdown"},
+		}, "boot → suspend"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sessions, err := CalculateSessionsAt(test.events, at(16))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(sessions) != 1 || sessions[0].Type != test.expected {
+				t.Errorf("expected a %q session, got:\n%s", test.expected, formatSessions(sessions))
+			}
+		})
+	}
+}
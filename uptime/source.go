@@ -0,0 +1,168 @@
+package uptime
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"time"
+)
+
+// EventSource produces the chronological list of boot, shutdown, suspend and
+// resume events that sessions are calculated from.
+type EventSource interface {
+	Events() ([]Event, error)
+}
+
+// Config selects where GetSystemEvents reads events from.
+type Config struct {
+	// Source is one of journal, wtmp, pmset, eventlog or auto
+	Source string
+	// Runner executes external commands, ExecRunner when nil
+	Runner CommandRunner
+	// Context cancels the commands of the ExecRunner, nil never does
+	Context context.Context
+	// DedupWindow is passed to DeduplicateEvents
+	DedupWindow time.Duration
+	// Now returns the current time, time.Now when nil
+	Now func() time.Time
+
+	// JournalCommand is the journalctl executable, "journalctl" from PATH
+	// when empty
+	JournalCommand string
+	// JournalDirectory and JournalMachine select the journal to read, see
+	// journalctl --directory and --machine
+	JournalDirectory string
+	JournalMachine   string
+	// JournalBoot limits the journal to one boot, given as an offset like
+	// journalctl -b (0 is the current boot, -1 the previous) or a boot ID
+	JournalBoot string
+	// CurrentBootCutoff is how recent the last entry of an exported
+	// journal must be for its last boot to be still running
+	CurrentBootCutoff time.Duration
+
+	// ShutdownInitiator adds who requested a shutdown to its reason
+	ShutdownInitiator bool
+
+	// SkipSuspend does not read suspend and hibernate events
+	SkipSuspend bool
+	// SuspendWorkers is the number of concurrent per-boot suspend queries,
+	// zero reads the whole journal at once
+	SuspendWorkers int
+
+	// JournalCache is a file to cache journal results of ended boots in
+	JournalCache string
+
+	// Input reads captured journal text instead of running journalctl,
+	// see ReadJournalText
+	Input io.Reader
+
+	// Logf reports progress, e.g. every journalctl call and how long it
+	// took. Nil discards it.
+	Logf func(format string, args ...any)
+}
+
+// ValidateSource checks that the source name is known.
+func ValidateSource(source string) error {
+	switch source {
+	case "journal", "wtmp", "pmset", "eventlog", "auto", "":
+		return nil
+	default:
+		return fmt.Errorf("unknown source %q, expected journal, wtmp, pmset, eventlog or auto", source)
+	}
+}
+
+// GetSystemEvents reads events from the configured source and removes
+// duplicates. The events are sorted chronologically.
+func GetSystemEvents(config Config) ([]Event, error) {
+	runner := config.Runner
+	if runner == nil {
+		runner = ExecRunner{Context: config.Context, Logf: config.Logf}
+	}
+
+	events, err := readEvents(config, runner)
+	if err != nil {
+		return nil, err
+	}
+
+	deduplicated := DeduplicateEvents(events, config.DedupWindow)
+	if config.Logf != nil {
+		config.Logf("read %d events, %d after removing duplicates", len(events), len(deduplicated))
+	}
+	return deduplicated, nil
+}
+
+// readEvents reads events from the requested source. In "auto" mode macOS
+// uses pmset and Windows the event log, elsewhere the journal is preferred
+// and wtmp is used only if the journal cannot be read.
+func readEvents(config Config, runner CommandRunner) ([]Event, error) {
+	if config.Input != nil {
+		now := time.Now()
+		if config.Now != nil {
+			now = config.Now()
+		}
+		return ReadJournalText(config.Input, now)
+	}
+
+	source := config.Source
+	journal := NewJournal(runner)
+	journal.Command = config.JournalCommand
+	journal.Directory = config.JournalDirectory
+	journal.Machine = config.JournalMachine
+	journal.Boot = config.JournalBoot
+	journal.CurrentBootCutoff = config.CurrentBootCutoff
+	journal.Now = config.Now
+	journal.ShutdownInitiator = config.ShutdownInitiator
+	journal.CachePath = config.JournalCache
+	journal.SkipSuspend = config.SkipSuspend
+	journal.SuspendWorkers = config.SuspendWorkers
+	journal.Logf = config.Logf
+
+	if (source == "auto" || source == "") && runtime.GOOS == "darwin" {
+		source = "pmset"
+	}
+	if (source == "auto" || source == "") && runtime.GOOS == "windows" {
+		source = "eventlog"
+	}
+
+	if journal.Boot != "" && source != "journal" && source != "auto" && source != "" {
+		return nil, fmt.Errorf("selecting a boot requires the journal source")
+	}
+
+	switch source {
+	case "journal":
+		return journal.Events()
+	case "wtmp":
+		return NewWtmp(runner).Events()
+	case "pmset":
+		pmset := NewPmset(runner)
+		if pmset == nil {
+			return nil, fmt.Errorf("the pmset source is only available on macOS")
+		}
+		return pmset.Events()
+	case "eventlog":
+		eventLog := NewEventLog(runner)
+		if eventLog == nil {
+			return nil, fmt.Errorf("the eventlog source is only available on Windows")
+		}
+		return eventLog.Events()
+	case "auto", "":
+		events, err := journal.Events()
+		if err == nil {
+			return events, nil
+		}
+
+		// Local wtmp says nothing about a foreign journal
+		if journal.Directory != "" || journal.Machine != "" || journal.Boot != "" {
+			return nil, err
+		}
+
+		events, wtmpErr := NewWtmp(runner).Events()
+		if wtmpErr != nil {
+			return nil, fmt.Errorf("%v; fallback to wtmp failed: %v", err, wtmpErr)
+		}
+		return events, nil
+	default:
+		return nil, ValidateSource(source)
+	}
+}
@@ -0,0 +1,271 @@
+package uptime
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Summary holds statistics calculated over a list of sessions.
+type Summary struct {
+	Count    int
+	Total    time.Duration
+	Average  time.Duration
+	Median   time.Duration
+	StdDev   time.Duration
+	Longest  Session
+	Shortest Session
+	Crashes  int
+	// FailedSuspends counts suspends that never resumed, they are not
+	// included in Crashes
+	FailedSuspends int
+	// FailedHibernations counts the hibernations among FailedSuspends
+	FailedHibernations int
+
+	// Suspended and Suspends include the sleeps inside sessions merged by
+	// MergeSuspends, which does not tell hibernations apart
+	Suspended    time.Duration
+	Hibernated   time.Duration
+	Suspends     int
+	Hibernations int
+
+	// BusiestDay and QuietestDay are the calendar days with the most and
+	// the least uptime, days without any uptime are not considered
+	BusiestDay  DayUptime
+	QuietestDay DayUptime
+	// CurrentStreak is the number of consecutive days with uptime up to the
+	// last day of the sessions, LongestStreak the longest such run
+	CurrentStreak int
+	LongestStreak int
+
+	// ByType sums the sessions per type, most uptime first
+	ByType []TypeTotal
+}
+
+// TypeTotal is the number and the uptime of the sessions of one type, e.g.
+// "resume → suspend". Reasons like "(reboot)" are not part of the type.
+type TypeTotal struct {
+	Type  string
+	Count int
+	Total time.Duration
+}
+
+// Summarize calculates the statistics of the sessions, the current streak
+// is counted up to today
+func Summarize(sessions []Session) Summary {
+	return SummarizeAt(sessions, time.Now())
+}
+
+// SummarizeAt is Summarize with the current time given
+func SummarizeAt(sessions []Session, now time.Time) Summary {
+	summary := Summary{Count: len(sessions)}
+	if len(sessions) == 0 {
+		return summary
+	}
+
+	for _, session := range sessions {
+		summary.Total += session.Duration
+		if IsFailedSuspend(session) {
+			summary.FailedSuspends++
+			if SessionStart(session) == "hibernate" {
+				summary.FailedHibernations++
+			}
+		} else if IsCrash(session) {
+			summary.Crashes++
+		}
+
+		summary.Suspends += session.Suspends
+		summary.Suspended += session.AsleepWithin
+		switch SessionEnd(session) {
+		case "suspend":
+			summary.Suspends++
+			summary.Suspended += session.Asleep
+		case "hibernate":
+			summary.Hibernations++
+			summary.Hibernated += session.Asleep
+		}
+	}
+	summary.Average = summary.Total / time.Duration(len(sessions))
+
+	durations := make([]time.Duration, len(sessions))
+	for i, session := range sessions {
+		durations[i] = session.Duration
+	}
+	summary.Median = median(durations)
+	summary.StdDev = stdDev(durations, summary.Average)
+
+	// Longest and shortest session
+	summary.Longest = sessions[0]
+	summary.Shortest = sessions[0]
+	for _, session := range sessions[1:] {
+		if session.Duration > summary.Longest.Duration {
+			summary.Longest = session
+		}
+		if session.Duration < summary.Shortest.Duration {
+			summary.Shortest = session
+		}
+	}
+
+	summary.addDailyStats(sessions, now)
+	summary.ByType = GroupByType(sessions)
+
+	return summary
+}
+
+// GroupByType sums the sessions per type, most uptime first
+func GroupByType(sessions []Session) []TypeTotal {
+	totals := []TypeTotal{}
+	index := map[string]int{}
+	for _, session := range sessions {
+		sessionType := groupType(session)
+		i, ok := index[sessionType]
+		if !ok {
+			i = len(totals)
+			index[sessionType] = i
+			totals = append(totals, TypeTotal{Type: sessionType})
+		}
+		totals[i].Count++
+		totals[i].Total += session.Duration
+	}
+
+	sort.SliceStable(totals, func(i, j int) bool {
+		return totals[i].Total > totals[j].Total
+	})
+	return totals
+}
+
+// groupType is the session type without the reason of its end, e.g.
+// "boot → shutdown" for "boot → shutdown (reboot)"
+func groupType(session Session) string {
+	start, end, found := strings.Cut(session.Type, " → ")
+	if !found || strings.HasPrefix(end, "(") {
+		return session.Type
+	}
+	end, _, _ = strings.Cut(end, " (")
+	return start + " → " + end
+}
+
+// addDailyStats fills in the busiest and quietest days and the streaks. The
+// current streak is the one ending today, or yesterday when the machine was
+// not up yet today.
+func (summary *Summary) addDailyStats(sessions []Session, now time.Time) {
+	from, to := sessions[0].Start, sessions[0].End
+	for _, session := range sessions[1:] {
+		if session.Start.Before(from) {
+			from = session.Start
+		}
+		if session.End.After(to) {
+			to = session.End
+		}
+	}
+	if now = now.In(to.Location()); now.After(to) {
+		to = now
+	}
+
+	streak, yesterday := 0, 0
+	days := UptimeByDay(sessions, from, to)
+	for _, day := range days {
+		yesterday = streak
+		if day.Uptime <= 0 {
+			streak = 0
+			continue
+		}
+
+		streak++
+		summary.LongestStreak = max(summary.LongestStreak, streak)
+
+		if summary.BusiestDay.Uptime == 0 || day.Uptime > summary.BusiestDay.Uptime {
+			summary.BusiestDay = day
+		}
+		if summary.QuietestDay.Uptime == 0 || day.Uptime < summary.QuietestDay.Uptime {
+			summary.QuietestDay = day
+		}
+	}
+
+	summary.CurrentStreak = streak
+	if streak == 0 && len(days) > 0 && days[len(days)-1].Day.Equal(StartOfDay(now)) {
+		summary.CurrentStreak = yesterday
+	}
+}
+
+// SessionEnd returns the event that closed the session, e.g. "suspend" for
+// a "boot → suspend" session.
+func SessionEnd(session Session) string {
+	_, end, found := strings.Cut(session.Type, "→ ")
+	if !found {
+		return ""
+	}
+	end, _, _ = strings.Cut(end, " ")
+	return end
+}
+
+// SessionStart returns the event that opened the session, e.g. "boot" for
+// a "boot → suspend" session.
+func SessionStart(session Session) string {
+	start, _, _ := strings.Cut(session.Type, " →")
+	return start
+}
+
+func IsCrash(session Session) bool {
+	return strings.HasSuffix(session.Type, "→ crash")
+}
+
+// IsFailedSuspend reports whether the session is the time between a suspend
+// or hibernate that never resumed and the crash that ended the boot
+func IsFailedSuspend(session Session) bool {
+	start := SessionStart(session)
+	return (start == "suspend" || start == "hibernate") && IsCrash(session)
+}
+
+// CurrentSession returns the last session if the machine is still in it or
+// asleep after it. ok is false when the history does not reach the present.
+func CurrentSession(sessions []Session) (session Session, asleep bool, ok bool) {
+	if len(sessions) == 0 {
+		return Session{}, false, false
+	}
+
+	last := sessions[len(sessions)-1]
+	switch {
+	case strings.HasSuffix(last.Type, "(still active)"):
+		return last, false, true
+	case strings.HasSuffix(last.Type, "(asleep)"):
+		return last, true, true
+	default:
+		return Session{}, false, false
+	}
+}
+
+func median(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// stdDev returns the population standard deviation around mean
+func stdDev(durations []time.Duration, mean time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+
+	variance := 0.0
+	for _, d := range durations {
+		diff := float64(d - mean)
+		variance += diff * diff
+	}
+	variance /= float64(len(durations))
+
+	return time.Duration(math.Sqrt(variance))
+}
@@ -0,0 +1,24 @@
+package uptime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarizeMergedSuspends(t *testing.T) {
+	sessions := []Session{
+		{Start: at(8), End: at(10), Duration: 2 * time.Hour, Type: "boot → suspend", Asleep: time.Hour},
+		{Start: at(11), End: at(13), Duration: 2 * time.Hour, Type: "resume → suspend", Asleep: time.Hour},
+		{Start: at(14), End: at(18), Duration: 4 * time.Hour, Type: "resume → shutdown"},
+	}
+
+	split := SummarizeAt(sessions, at(20))
+	merged := SummarizeAt(MergeSuspends(sessions), at(20))
+	if merged.Count != 1 || merged.Total != split.Total {
+		t.Errorf("expected one session of %s, got %d of %s", split.Total, merged.Count, merged.Total)
+	}
+	if merged.Suspends != split.Suspends || merged.Suspended != split.Suspended {
+		t.Errorf("expected %d suspends for %s, got %d for %s",
+			split.Suspends, split.Suspended, merged.Suspends, merged.Suspended)
+	}
+}
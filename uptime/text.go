@@ -0,0 +1,96 @@
+package uptime
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ReadJournalText reads events from captured journal text instead of
+// running journalctl, e.g. logs someone sent by mail. The input is the
+// table printed by journalctl --list-boots, optionally followed by
+// journalctl -o short-iso lines of systemd-suspend.service,
+// systemd-hibernate.service, shutdown.target and reboot.target. The current
+// boot (index 0) lasts until now.
+//
+// Without any shutdown.target or reboot.target lines every ended boot is
+// assumed to have been shut down cleanly. With them, a boot without one
+// ended in a crash.
+func ReadJournalText(r io.Reader, now time.Time) ([]Event, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read journal text: %v", err)
+	}
+
+	// Journal lines start with their timestamp, the rest is the boot list
+	var listing, logs bytes.Buffer
+	for _, line := range strings.Split(string(data), "\n") {
+		if _, ok := parseLineTimestamp(line); ok {
+			logs.WriteString(line + "\n")
+		} else {
+			listing.WriteString(line + "\n")
+		}
+	}
+
+	boots := parseBootsText(listing.Bytes(), now)
+	if len(boots) == 0 {
+		return nil, fmt.Errorf("no boots found in the journal text, expected the output of journalctl --list-boots")
+	}
+
+	targets := parseTargetLines(logs.Bytes())
+
+	events := []Event{}
+	for _, boot := range boots {
+		events = append(events, Event{Timestamp: boot.StartTime, Type: "boot", BootID: boot.ID})
+		if boot.Index == 0 {
+			continue
+		}
+
+		end := Event{Timestamp: boot.EndTime, Type: "shutdown", BootID: boot.ID}
+		if len(targets) > 0 {
+			end.Type = "crash"
+			for _, target := range targets {
+				if target.Timestamp.Before(boot.StartTime) || target.Timestamp.After(boot.EndTime) {
+					continue
+				}
+				// A reboot also reaches shutdown.target
+				end.Type = "shutdown"
+				if target.Reason != "" {
+					end.Reason = target.Reason
+				}
+			}
+		}
+		events = append(events, end)
+	}
+	events = append(events, parseSleepLines(logs.Bytes())...)
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+	return events, nil
+}
+
+// parseTargetLines reads when shutdown.target or reboot.target was reached,
+// as shutdown events with "reboot" as the reason of a reboot
+func parseTargetLines(output []byte) []Event {
+	events := []Event{}
+	for _, line := range strings.Split(string(output), "\n") {
+		text := strings.ToLower(line)
+		reason := ""
+		switch {
+		case strings.Contains(text, "reached target reboot"):
+			reason = "reboot"
+		case strings.Contains(text, "reached target shutdown"):
+		default:
+			continue
+		}
+
+		if timestamp, ok := parseLineTimestamp(line); ok {
+			events = append(events, Event{Timestamp: timestamp, Type: "shutdown", Reason: reason})
+		}
+	}
+	return events
+}
@@ -0,0 +1,21 @@
+package uptime
+
+import "time"
+
+// ConvertEvents moves event timestamps into loc.
+func ConvertEvents(events []Event, loc *time.Location) []Event {
+	for i := range events {
+		events[i].Timestamp = events[i].Timestamp.In(loc)
+	}
+	return events
+}
+
+// ConvertSessions moves session boundaries into loc. Durations are spans
+// between instants, so they are not affected.
+func ConvertSessions(sessions []Session, loc *time.Location) []Session {
+	for i := range sessions {
+		sessions[i].Start = sessions[i].Start.In(loc)
+		sessions[i].End = sessions[i].End.In(loc)
+	}
+	return sessions
+}
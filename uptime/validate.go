@@ -0,0 +1,41 @@
+package uptime
+
+import (
+	"fmt"
+	"time"
+)
+
+// RepairSessions drops sessions that do not end after they start and trims
+// sessions that overlap the previous one of the same host, which clock
+// changes can cause.
+// Sessions must be sorted by start. It returns a warning for every change,
+// so a single bad journal entry is reported instead of corrupting the rest.
+func RepairSessions(sessions []Session) ([]Session, []string) {
+	result := []Session{}
+	warnings := []string{}
+	// Index of the last session of each host in result
+	last := map[string]int{}
+
+	for _, session := range sessions {
+		if i, ok := last[session.Host]; ok {
+			previous := result[i]
+			if session.Start.Before(previous.End) {
+				warnings = append(warnings, fmt.Sprintf("session %s %s overlaps the previous one, starting it at %s",
+					session.Start.Format(time.RFC3339), session.Type, previous.End.Format(time.RFC3339)))
+				session.Start = previous.End
+				session.Duration = session.End.Sub(session.Start)
+			}
+		}
+
+		if session.Duration <= 0 || !session.End.After(session.Start) {
+			warnings = append(warnings, fmt.Sprintf("session %s %s does not end after it starts, dropping it",
+				session.Start.Format(time.RFC3339), session.Type))
+			continue
+		}
+
+		last[session.Host] = len(result)
+		result = append(result, session)
+	}
+
+	return result, warnings
+}
@@ -0,0 +1,73 @@
+package uptime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRepairSessions(t *testing.T) {
+	session := func(start, end int) Session {
+		return Session{Start: at(start), End: at(end), Duration: at(end).Sub(at(start)), Type: "boot → shutdown"}
+	}
+	onHost := func(host string, session Session) Session {
+		session.Host = host
+		return session
+	}
+
+	tests := []struct {
+		name     string
+		sessions []Session
+		expected []Session
+		warnings int
+	}{
+		{
+			name:     "no overlap",
+			sessions: []Session{session(8, 10), session(11, 12)},
+			expected: []Session{session(8, 10), session(11, 12)},
+		},
+		{
+			name:     "overlapping boots are trimmed",
+			sessions: []Session{session(8, 12), session(10, 14)},
+			expected: []Session{session(8, 12), session(12, 14)},
+			warnings: 1,
+		},
+		{
+			name:     "boot inside the previous one is dropped",
+			sessions: []Session{session(8, 14), session(10, 12)},
+			expected: []Session{session(8, 14)},
+			warnings: 2,
+		},
+		{
+			name:     "session ending before it starts is dropped",
+			sessions: []Session{session(8, 10), session(12, 11)},
+			expected: []Session{session(8, 10)},
+			warnings: 1,
+		},
+		{
+			name:     "sessions of other hosts may overlap",
+			sessions: []Session{onHost("a", session(8, 12)), onHost("b", session(10, 14)), onHost("a", session(11, 13))},
+			expected: []Session{onHost("a", session(8, 12)), onHost("b", session(10, 14)), onHost("a", session(12, 13))},
+			warnings: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, warnings := RepairSessions(test.sessions)
+			if len(warnings) != test.warnings {
+				t.Errorf("expected %d warnings, got %q", test.warnings, warnings)
+			}
+			if actual := formatSessions(actual); actual != formatSessions(test.expected) {
+				t.Fatalf("unexpected sessions:\n%s\nexpected:\n%s", actual, formatSessions(test.expected))
+			}
+			for i, session := range actual {
+				if session.Host != test.expected[i].Host {
+					t.Errorf("expected session %d on host %q, got %q", i, test.expected[i].Host, session.Host)
+				}
+				if session.Duration != session.End.Sub(session.Start) {
+					t.Errorf("duration %s of %s does not match its span", session.Duration, session.Start.Format(time.RFC3339))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package uptime
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Wtmp reads boot and shutdown records from the login accounting database
+// using last(1). It is used on systems without a readable systemd journal.
+type Wtmp struct {
+	runner CommandRunner
+}
+
+// NewWtmp returns a source backed by last(1).
+func NewWtmp(runner CommandRunner) *Wtmp {
+	return &Wtmp{runner: runner}
+}
+
+func (w *Wtmp) Events() ([]Event, error) {
+	output, err := w.runner.Run("last", "-x", "-F", "reboot", "shutdown")
+	if err != nil {
+		return nil, fmt.Errorf("cannot read wtmp records: %v", err)
+	}
+
+	events := []Event{}
+
+	// Full time format (-F), e.g. "Sun Oct 29 09:13:10 2023"
+	dateRegex := regexp.MustCompile(`\w{3} \w{3} [ \d]\d \d{2}:\d{2}:\d{2} \d{4}`)
+
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		line := scanner.Text()
+		// Format: reboot   system boot  6.1.0-13-amd64   Sun Oct 29 09:13:10 2023 - Sun Oct 29 22:11:05 2023  (12:57)
+		//         shutdown system down  6.1.0-13-amd64   Sun Oct 29 22:11:05 2023 - Mon Oct 30 08:00:01 2023  (09:48)
+
+		eventType := ""
+		switch {
+		case strings.HasPrefix(line, "reboot "):
+			eventType = "boot"
+		case strings.HasPrefix(line, "shutdown "):
+			eventType = "shutdown"
+		default:
+			continue
+		}
+
+		date := dateRegex.FindString(line)
+		if date == "" {
+			continue
+		}
+
+		timestamp, err := time.ParseInLocation("Mon Jan _2 15:04:05 2006", date, time.Local)
+		if err != nil {
+			continue
+		}
+
+		events = append(events, Event{
+			Timestamp: timestamp,
+			Type:      eventType,
+		})
+	}
+
+	// last(1) lists the newest records first
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+
+	return events, nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keskad/loco/uptime"
+)
+
+// kernelUptime reads how long ago the kernel booted, suspended time
+// included
+func kernelUptime() (time.Duration, error) {
+	data, err := os.ReadFile("/proc/uptime")
+	if err != nil {
+		return 0, fmt.Errorf("cannot read the kernel uptime: %v", err)
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("cannot read the kernel uptime: /proc/uptime is empty")
+	}
+	seconds, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("cannot read the kernel uptime: %v", err)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// verifyUptime compares the time since the last boot in the history with
+// the kernel uptime. A difference above tolerance usually means an event
+// was parsed wrong, so a warning goes to stderr.
+func verifyUptime(w io.Writer, sessions []uptime.Session, tolerance time.Duration, settings renderSettings) error {
+	kernel, err := kernelUptime()
+	if err != nil {
+		return err
+	}
+
+	var lastBoot *uptime.Session
+	for i := len(sessions) - 1; i >= 0; i-- {
+		if uptime.SessionStart(sessions[i]) == "boot" {
+			lastBoot = &sessions[i]
+			break
+		}
+	}
+	if lastBoot == nil {
+		return fmt.Errorf("cannot verify: no boot found in the history")
+	}
+
+	computed := now().Sub(lastBoot.Start)
+	delta := computed - kernel
+
+	fmt.Fprintln(w, "\n=== Verification ===")
+	fmt.Fprintf(w, "Kernel uptime: %s\n", settings.formatDuration(kernel))
+	fmt.Fprintf(w, "Since last boot: %s\n", settings.formatDuration(computed))
+	fmt.Fprintf(w, "Difference: %s\n", settings.formatDuration(delta))
+
+	if delta > tolerance || delta < -tolerance {
+		fmt.Fprintf(os.Stderr, "Warning: the history is %s off the kernel uptime, a boot or suspend event may have been parsed wrong\n", settings.formatDuration(delta))
+	}
+	return nil
+}
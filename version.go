@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// Set at build time, see the Makefile:
+// go build -ldflags "-X main.version=1.2.0 -X main.commit=abc1234 -X main.date=2025-11-01"
+var (
+	version = ""
+	commit  = ""
+	date    = ""
+)
+
+// versionString describes the build for --version. Values not given by
+// -ldflags are taken from the build info Go embeds in the binary.
+func versionString() string {
+	v, c, d := version, commit, date
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if v == "" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			v = info.Main.Version
+		}
+		for _, setting := range info.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && c == "":
+				c = setting.Value
+			case setting.Key == "vcs.time" && d == "":
+				d = setting.Value
+			}
+		}
+	}
+
+	if v == "" {
+		v = "dev"
+	}
+	if c == "" {
+		c = "unknown"
+	}
+	if d == "" {
+		d = "unknown"
+	}
+	return fmt.Sprintf("uptime-history %s (commit %s, built %s)", v, c, d)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const defaultWatchInterval = 60 * time.Second
+
+// watchInterval is the value of --watch. It behaves like a boolean flag, so
+// a bare --watch uses the default interval, while --watch=30s sets one.
+type watchInterval time.Duration
+
+func (w *watchInterval) String() string {
+	return time.Duration(*w).String()
+}
+
+func (w *watchInterval) Set(value string) error {
+	switch value {
+	case "true":
+		*w = watchInterval(defaultWatchInterval)
+	case "false":
+		*w = 0
+	default:
+		interval, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		if interval <= 0 {
+			return fmt.Errorf("interval must be positive")
+		}
+		*w = watchInterval(interval)
+	}
+	return nil
+}
+
+func (w *watchInterval) IsBoolFlag() bool {
+	return true
+}
+
+// watch redraws the report every interval until interrupted by SIGINT or
+// SIGTERM, which ends it with exit code 0
+func watch(opts options) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ticker := time.NewTicker(time.Duration(opts.watch))
+	defer ticker.Stop()
+
+	redraw := opts.outputFormat == "" && opts.outputFile == ""
+	if redraw {
+		// Interrupting in the middle of a colored row must not leave the
+		// terminal colored, nor without a cursor
+		defer fmt.Print("\033[0m\033[?25h\n")
+	}
+
+	for {
+		if redraw {
+			// Move the cursor home and clear the screen
+			fmt.Print("\033[H\033[2J")
+		}
+
+		// A failed refresh is reported, the next one may succeed. One
+		// interrupted by the signal has nothing to report.
+		if err := report(ctx, opts); err != nil && ctx.Err() == nil && !errors.Is(err, errCrashDetected) {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}